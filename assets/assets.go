@@ -0,0 +1,67 @@
+// Package assets bundles the default template pack, font and emoji sprites into the
+// binary so the service works without a template directory, font file or emoji
+// directory on disk.
+package assets
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// templates holds the default template pack: a manifest and its images
+//
+//go:embed templates
+var templates embed.FS
+
+// font is Oswald, a condensed Impact-style sans-serif released under the SIL Open Font
+// License (see fonts/LICENSE-Oswald). It only covers Latin scripts.
+//
+//go:embed fonts/Oswald-Regular.ttf
+var font []byte
+
+// fallbackFont is Go Bold, a heavy sans-serif released under a BSD license (see
+// fonts/LICENSE-Go). It covers the Greek and Cyrillic text the bundled font has no glyphs for.
+//
+//go:embed fonts/Go-Bold.ttf
+var fallbackFont []byte
+
+// FontName describes the bundled font in logs
+const FontName = "Oswald"
+
+// emoji holds the Twemoji 72x72 sprites, released under CC-BY 4.0 (see emoji/LICENSE)
+//
+//go:embed emoji/*.png
+var emoji embed.FS
+
+// EmojiName describes the bundled emoji sprites in logs
+const EmojiName = "Twemoji"
+
+// Templates returns the default template pack, laid out like a template directory
+func Templates() fs.FS {
+	pack, err := fs.Sub(templates, "templates")
+	if err != nil {
+		// The directory is embedded at build time, so this cannot happen
+		panic(err)
+	}
+	return pack
+}
+
+// Font returns the bundled TrueType font
+func Font() []byte {
+	return font
+}
+
+// FallbackFont returns the bundled TrueType font used for characters Font has no glyph for
+func FallbackFont() []byte {
+	return fallbackFont
+}
+
+// Emoji returns the bundled emoji sprites, laid out like an emoji directory
+func Emoji() fs.FS {
+	sprites, err := fs.Sub(emoji, "emoji")
+	if err != nil {
+		// The directory is embedded at build time, so this cannot happen
+		panic(err)
+	}
+	return sprites
+}
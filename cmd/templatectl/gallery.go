@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// runGallery renders every template with its example captions and writes a static HTML
+// gallery. It exits non-zero when any template could not be rendered.
+func runGallery(args []string) int {
+	cfg := loadConfig()
+
+	flags := flag.NewFlagSet("gallery", flag.ExitOnError)
+	dir := flags.String("dir", cfg.TemplateDir, "template directory to render")
+	outDir := flags.String("out", "gallery", "directory to write the gallery to")
+	size := flags.String("size", service.DefaultPreviewSize, "thumbnail size: small, medium or large")
+	flags.Parse(args)
+
+	if _, supported := service.PreviewSizes[*size]; !supported {
+		fmt.Fprintf(os.Stderr, "templatectl gallery: unknown size %q\n", *size)
+		return 2
+	}
+
+	// Load the catalog the way the service does, so missing directories fall back to
+	// the built-in pack and templates failing verification are left out, without
+	// writing version archives into the directory
+	cfg.TemplateDir = *dir
+	memeService := &service.MemeService{Config: cfg}
+	if err := memeService.LoadCatalog(); err != nil {
+		fmt.Fprintf(os.Stderr, "templatectl gallery: %v\n", err)
+		return 1
+	}
+
+	report, err := memeService.WriteGallery(*outDir, *size)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "templatectl gallery: %v\n", err)
+		return 1
+	}
+
+	for _, failure := range report.Failed {
+		fmt.Fprintf(os.Stderr, "failed   %s\n", failure)
+	}
+	fmt.Printf("Wrote %d templates to %s\n", report.Templates, report.Index)
+
+	if len(report.Failed) > 0 {
+		return 1
+	}
+	return 0
+}
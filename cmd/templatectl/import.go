@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// runImport adds the templates of an Imgflip-style catalog to the template directory.
+// Existing ids are reported as conflicts and never overwritten. It exits non-zero
+// when any entry failed to import.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	catalogPath := flags.String("catalog", "", "Imgflip-style JSON catalog to import (required)")
+	imageDir := flags.String("images", "", "directory holding the catalog images (required)")
+	category := flags.String("category", "imported", "category assigned to imported templates")
+	flags.Parse(args)
+
+	if *catalogPath == "" || *imageDir == "" {
+		fmt.Fprintln(os.Stderr, "templatectl import: -catalog and -images are required")
+		flags.Usage()
+		return 2
+	}
+
+	catalogFile, err := os.Open(*catalogPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "templatectl import: %v\n", err)
+		return 1
+	}
+	entries, err := service.ParseImgflipCatalog(catalogFile)
+	catalogFile.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "templatectl import: %v\n", err)
+		return 1
+	}
+
+	memeService, err := newStoreService()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "templatectl import: %v\n", err)
+		return 1
+	}
+
+	counts := make(map[string]int)
+	for _, result := range memeService.ImportImgflipTemplates(entries, *imageDir, *category) {
+		counts[result.Status]++
+		if result.Message != "" {
+			fmt.Printf("%-8s %s (%s): %s\n", result.Status, result.TemplateID, result.SourceID, result.Message)
+		} else {
+			fmt.Printf("%-8s %s (%s)\n", result.Status, result.TemplateID, result.SourceID)
+		}
+	}
+	fmt.Printf("%d imported, %d conflicts, %d failed\n", counts[service.ImportImported], counts[service.ImportConflict], counts[service.ImportFailed])
+
+	if counts[service.ImportFailed] > 0 {
+		return 1
+	}
+	return 0
+}
+
+// newStoreService opens the template store in TEMPLATE_DIR, creating the directory if needed.
+// An invalid manifest is an error: writing to the store would drop the entries it could not read.
+func newStoreService() (*service.MemeService, error) {
+	cfg := loadConfig()
+	if err := os.MkdirAll(cfg.TemplateDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create template directory: %v", err)
+	}
+
+	templates := map[string]*service.TemplateInfo{}
+	if _, err := os.Stat(filepath.Join(cfg.TemplateDir, service.ManifestFilename)); err == nil {
+		templates, err = service.LoadTemplates(cfg.TemplateDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &service.MemeService{
+		Templates: templates,
+		Config:    cfg,
+	}, nil
+}
+
+// loadConfig reads the configuration without logging it, keeping the command output to the results
+func loadConfig() *config.Config {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	return config.LoadConfig()
+}
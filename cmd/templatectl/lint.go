@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// runLint checks every template in the template directory and prints a JSON report.
+// It exits non-zero when any error was found; warnings alone do not fail.
+func runLint(args []string) int {
+	cfg := loadConfig()
+
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	dir := flags.String("dir", cfg.TemplateDir, "template directory to lint")
+	maxDimension := flags.Int("max-dimension", cfg.MaxTemplateDimension, "largest supported image width or height")
+	flags.Parse(args)
+
+	report := service.LintTemplates(*dir, *maxDimension)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "templatectl lint: %v\n", err)
+		return 1
+	}
+
+	if report.Errors > 0 {
+		return 1
+	}
+	return 0
+}
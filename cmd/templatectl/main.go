@@ -0,0 +1,48 @@
+// Command templatectl manages the template catalog in TEMPLATE_DIR from the command line.
+//
+// Usage:
+//
+//	templatectl gallery [-dir ./templates] [-out ./gallery] [-size medium]
+//	templatectl import -catalog memes.json -images ./images [-category imported]
+//	templatectl lint [-dir ./templates] [-max-dimension 4096]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// commands maps subcommand names to their implementations; each returns the exit code
+var commands = map[string]func(args []string) int{
+	"gallery": runGallery,
+	"import":  runImport,
+	"lint":    runLint,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	run, exists := commands[os.Args[1]]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "templatectl: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	os.Exit(run(os.Args[2:]))
+}
+
+// usage prints the available subcommands
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: templatectl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  gallery  Render a static HTML gallery of every template")
+	fmt.Fprintln(os.Stderr, "  import   Import templates from an Imgflip-style JSON catalog")
+	fmt.Fprintln(os.Stderr, "  lint     Check every template in the template directory")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Run 'templatectl <command> -h' for the flags of a command.")
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,17 +13,35 @@ import (
 type Config struct {
 	// Server configuration
 	Port                  string
+	HTTPPort              string
 	GRPCMaxConnectionAge  time.Duration
 	GRPCMaxConnectionIdle time.Duration
 	GRPCKeepaliveTime     time.Duration
 	GRPCKeepaliveTimeout  time.Duration
 
 	// Service configuration
-	TemplateDir  string
-	FontFile     string
-	ImageQuality int
-	FontSize     float64
-	LineSpacing  float64
+	TemplateDir            string
+	TemplateReloadInterval time.Duration
+	FontFile               string
+	FontDir                string
+	FontFallbacks          []string
+	EmojiDir               string
+	ImageQuality           int
+	FontSize               float64
+	LineSpacing            float64
+	AutoFitText            bool
+	MinFontSize            float64
+	MaxFontSize            float64
+	TextOverflow           string
+
+	// Template management
+	AdminToken           string
+	MaxTemplateBytes     int64
+	MaxTemplateDimension int
+
+	// Usage statistics
+	StatsFile          string
+	StatsFlushInterval time.Duration
 
 	// Feature flags
 	EnableAICaption bool
@@ -32,27 +52,53 @@ func LoadConfig() *Config {
 	cfg := &Config{
 		// Server defaults
 		Port:                  GetEnv("PORT", "50051"),
+		HTTPPort:              GetEnv("HTTP_PORT", "8080"),
 		GRPCMaxConnectionAge:  GetDurationEnv("GRPC_MAX_CONNECTION_AGE", 30*time.Minute),
 		GRPCMaxConnectionIdle: GetDurationEnv("GRPC_MAX_CONNECTION_IDLE", 15*time.Minute),
 		GRPCKeepaliveTime:     GetDurationEnv("GRPC_KEEPALIVE_TIME", 5*time.Minute),
 		GRPCKeepaliveTimeout:  GetDurationEnv("GRPC_KEEPALIVE_TIMEOUT", 20*time.Second),
 
 		// Service defaults
-		TemplateDir:  GetEnv("TEMPLATE_DIR", "./templates"),
-		FontFile:     GetEnv("FONT_FILE", "./fonts/impact.ttf"),
-		ImageQuality: GetIntEnv("IMAGE_QUALITY", 90),
-		FontSize:     GetFloatEnv("FONT_SIZE", 36),
-		LineSpacing:  GetFloatEnv("LINE_SPACING", 1.5),
+		TemplateDir:            GetEnv("TEMPLATE_DIR", "./templates"),
+		TemplateReloadInterval: GetDurationEnv("TEMPLATE_RELOAD_INTERVAL", 30*time.Second),
+		FontFile:               GetEnv("FONT_FILE", "./fonts/impact.ttf"),
+		FontFallbacks:          GetListEnv("FONT_FALLBACK", nil),
+		EmojiDir:               GetEnv("EMOJI_DIR", "./emoji"),
+		ImageQuality:           GetIntEnv("IMAGE_QUALITY", 90),
+		FontSize:               GetFloatEnv("FONT_SIZE", 36),
+		LineSpacing:            GetFloatEnv("LINE_SPACING", 1.5),
+		AutoFitText:            GetBoolEnv("AUTO_FIT_TEXT", true),
+		MinFontSize:            GetFloatEnv("MIN_FONT_SIZE", 12),
+		MaxFontSize:            GetFloatEnv("MAX_FONT_SIZE", 48),
+		TextOverflow:           GetChoiceEnv("TEXT_OVERFLOW", "ellipsis", "ellipsis", "overflow", "reject"),
+
+		// Template management defaults
+		AdminToken:           GetEnv("ADMIN_TOKEN", ""),
+		MaxTemplateBytes:     int64(GetIntEnv("MAX_TEMPLATE_BYTES", 10<<20)),
+		MaxTemplateDimension: GetIntEnv("MAX_TEMPLATE_DIMENSION", 4096),
+
+		// Usage statistics defaults
+		StatsFile:          GetEnv("STATS_FILE", "./data/usage-stats.json"),
+		StatsFlushInterval: GetDurationEnv("STATS_FLUSH_INTERVAL", time.Minute),
 
 		// Feature flags
 		EnableAICaption: GetBoolEnv("ENABLE_AI_CAPTION", false),
 	}
 
+	// Fonts live next to FONT_FILE unless FONT_DIR says otherwise
+	cfg.FontDir = GetEnv("FONT_DIR", filepath.Dir(cfg.FontFile))
+
 	// Log configuration
 	log.Println("Configuration loaded:")
 	log.Printf("- Server port: %s", cfg.Port)
+	log.Printf("- HTTP port: %s", cfg.HTTPPort)
 	log.Printf("- Template directory: %s", cfg.TemplateDir)
+	log.Printf("- Font directory: %s (fallbacks: %s)", cfg.FontDir, strings.Join(cfg.FontFallbacks, ", "))
+	log.Printf("- Emoji directory: %s", cfg.EmojiDir)
 	log.Printf("- AI caption enabled: %v", cfg.EnableAICaption)
+	log.Printf("- Caption auto-fit: %v (%g-%gpt, overflow: %s)", cfg.AutoFitText, cfg.MinFontSize, cfg.MaxFontSize, cfg.TextOverflow)
+	log.Printf("- Template admin API enabled: %v", cfg.AdminToken != "")
+	log.Printf("- Usage stats file: %s", cfg.StatsFile)
 
 	return cfg
 }
@@ -110,6 +156,38 @@ func GetBoolEnv(key string, defaultValue bool) bool {
 	return boolValue
 }
 
+// GetChoiceEnv retrieves an environment variable that must be one of choices or returns a default value
+func GetChoiceEnv(key, defaultValue string, choices ...string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	for _, choice := range choices {
+		if value == choice {
+			return value
+		}
+	}
+	log.Printf("Warning: Invalid value for %s, must be one of %s, using default: %v", key, strings.Join(choices, ", "), defaultValue)
+	return defaultValue
+}
+
+// GetListEnv retrieves a comma-separated environment variable as a list or returns a default value.
+// Items are trimmed and empty items dropped.
+func GetListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // GetDurationEnv retrieves an environment variable as a duration or returns a default value
 func GetDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	value := os.Getenv(key)
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// maxMetadataBytes caps the size of template metadata, in an upload or a PATCH body
+const maxMetadataBytes = 64 << 10
+
+// TemplateAdminInterface defines the template management operations the admin API needs
+type TemplateAdminInterface interface {
+	CreateTemplate(info *service.TemplateInfo, img io.Reader) (*service.TemplateInfo, error)
+	ReplaceTemplateImage(id string, img io.Reader) (*service.TemplateInfo, error)
+	UpdateTemplate(id string, update *service.TemplateUpdate) (*service.TemplateInfo, error)
+	DeleteTemplate(id string) error
+}
+
+// AdminHandler serves the HTTP template management API.
+// The shared gRPC contract has no admin RPCs, so template management is exposed over HTTP.
+type AdminHandler struct {
+	templates TemplateAdminInterface
+	token     string
+	mux       *http.ServeMux
+}
+
+// NewAdminHandler creates the admin API; requests must carry token as a bearer token
+func NewAdminHandler(templates TemplateAdminInterface, token string) *AdminHandler {
+	h := &AdminHandler{
+		templates: templates,
+		token:     token,
+		mux:       http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("POST /admin/templates", h.createTemplate)
+	h.mux.HandleFunc("PATCH /admin/templates/{id}", h.updateTemplate)
+	h.mux.HandleFunc("PUT /admin/templates/{id}/image", h.replaceTemplateImage)
+	h.mux.HandleFunc("DELETE /admin/templates/{id}", h.deleteTemplate)
+
+	return h
+}
+
+// ServeHTTP authenticates the request and dispatches it to the admin endpoints
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token == "" {
+		writeError(w, http.StatusNotFound, "template admin API is disabled")
+		return
+	}
+
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(h.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid admin token")
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+// createTemplate handles a multipart upload with a JSON "metadata" part followed by an
+// "image" part. The image is streamed to the store so large files are never held in memory.
+func (h *AdminHandler) createTemplate(w http.ResponseWriter, r *http.Request) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "expected a multipart/form-data upload")
+		return
+	}
+
+	var info *service.TemplateInfo
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read upload: %v", err))
+			return
+		}
+
+		switch part.FormName() {
+		case "metadata":
+			info = &service.TemplateInfo{}
+			if err := decodeJSON(io.LimitReader(part, maxMetadataBytes), info); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid metadata: %v", err))
+				return
+			}
+		case "image":
+			if info == nil {
+				writeError(w, http.StatusBadRequest, "the metadata part must precede the image part")
+				return
+			}
+			log.Printf("Admin: Creating template %s", info.ID)
+			created, err := h.templates.CreateTemplate(info, part)
+			if err != nil {
+				writeServiceError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+	}
+
+	writeError(w, http.StatusBadRequest, "upload is missing the image part")
+}
+
+// updateTemplate applies a JSON metadata update to a template
+func (h *AdminHandler) updateTemplate(w http.ResponseWriter, r *http.Request) {
+	var update service.TemplateUpdate
+	if err := decodeJSON(http.MaxBytesReader(w, r.Body, maxMetadataBytes), &update); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid update: %v", err))
+		return
+	}
+
+	log.Printf("Admin: Updating template %s", r.PathValue("id"))
+	updated, err := h.templates.UpdateTemplate(r.PathValue("id"), &update)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// replaceTemplateImage streams the request body in as the template's new image
+func (h *AdminHandler) replaceTemplateImage(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Admin: Replacing image of template %s", r.PathValue("id"))
+	updated, err := h.templates.ReplaceTemplateImage(r.PathValue("id"), r.Body)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// deleteTemplate removes a template and its image
+func (h *AdminHandler) deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Admin: Deleting template %s", r.PathValue("id"))
+	if err := h.templates.DeleteTemplate(r.PathValue("id")); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeJSON decodes a JSON body, rejecting unknown fields
+func decodeJSON(body io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeServiceError maps template store errors to HTTP status codes
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrTemplateNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrTemplateExists), errors.Is(err, service.ErrBuiltinTemplates):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidTemplate):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("Error managing templates: %v", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
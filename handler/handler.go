@@ -2,9 +2,33 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
 
 	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TemplateVersionKey is the gRPC metadata key that pins the template version in a
+// GenerateMeme request and reports the version used in the response header.
+// The shared proto has no version fields, so the version travels as metadata.
+const TemplateVersionKey = "template-version"
+
+// gRPC metadata keys that page, sort and search ListTemplates, with the meaning of the
+// page_size, page_token, sort and q parameters of the HTTP template search. The token of
+// the next page is returned in the NextPageTokenKey header.
+const (
+	PageSizeKey      = "page-size"
+	PageTokenKey     = "page-token"
+	SortKey          = "sort"
+	QueryKey         = "q"
+	NextPageTokenKey = "next-page-token"
 )
 
 // MemeServiceInterface defines the interface that the handler needs
@@ -13,6 +37,16 @@ type MemeServiceInterface interface {
 	ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error)
 }
 
+// VersionedMemeService is implemented by services that can render pinned template versions
+type VersionedMemeService interface {
+	GenerateMemeVersion(ctx context.Context, req *pb.GenerateMemeRequest, version string) (*pb.GenerateMemeResponse, string, error)
+}
+
+// PagedMemeService is implemented by services that can page, sort and search ListTemplates
+type PagedMemeService interface {
+	ListTemplatesPage(ctx context.Context, req *pb.ListTemplatesRequest, query *service.TemplateQuery) (*pb.ListTemplatesResponse, string, error)
+}
+
 // MemeHandler handles gRPC requests for the meme service
 type MemeHandler struct {
 	memeService MemeServiceInterface
@@ -37,14 +71,99 @@ func (h *MemeHandler) GenerateMeme(ctx context.Context, req *pb.GenerateMemeRequ
 		}, nil
 	}
 
+	versioned, supportsVersions := h.memeService.(VersionedMemeService)
+	if !supportsVersions {
+		// Call the service layer
+		return h.memeService.GenerateMeme(ctx, req)
+	}
+
+	// Pin the template version when the caller asks for one
+	var version string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(TemplateVersionKey); len(values) > 0 {
+			version = values[0]
+		}
+	}
+
 	// Call the service layer
-	return h.memeService.GenerateMeme(ctx, req)
+	resp, used, err := versioned.GenerateMemeVersion(ctx, req, version)
+	if err != nil || used == "" {
+		return resp, err
+	}
+
+	// Report the version used so the meme can be reproduced later
+	if err := grpc.SetHeader(ctx, metadata.Pairs(TemplateVersionKey, used)); err != nil {
+		log.Printf("Warning: failed to set template version header: %v", err)
+	}
+
+	return resp, nil
 }
 
 // ListTemplates handles requests to list available meme templates
 func (h *MemeHandler) ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error) {
 	log.Printf("Handler: Received request to list templates, category filter: %s", req.Category)
 
+	// Without paging metadata the whole catalog is listed, as before paging was added
+	paged, supportsPaging := h.memeService.(PagedMemeService)
+	query, err := templateQueryFromMetadata(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !supportsPaging || query == nil {
+		// Call the service layer
+		return h.memeService.ListTemplates(ctx, req)
+	}
+
 	// Call the service layer
-	return h.memeService.ListTemplates(ctx, req)
+	resp, next, err := paged.ListTemplatesPage(ctx, req, query)
+	if errors.Is(err, service.ErrInvalidQuery) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Report where the next page starts; there is no header on the last page
+	if next != "" {
+		if err := grpc.SetHeader(ctx, metadata.Pairs(NextPageTokenKey, next)); err != nil {
+			log.Printf("Warning: failed to set next page token header: %v", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// templateQueryFromMetadata reads the paging, sort and search metadata of a ListTemplates
+// request. It returns nil when the request has none of it.
+func templateQueryFromMetadata(ctx context.Context) (*service.TemplateQuery, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	get := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	query := &service.TemplateQuery{
+		Text:      get(QueryKey),
+		SortBy:    get(SortKey),
+		PageToken: get(PageTokenKey),
+	}
+	pageSize := get(PageSizeKey)
+	if pageSize != "" {
+		size, err := strconv.Atoi(pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", PageSizeKey)
+		}
+		query.PageSize = size
+	}
+
+	if pageSize == "" && query.Text == "" && query.SortBy == "" && query.PageToken == "" {
+		return nil, nil
+	}
+	return query, nil
 }
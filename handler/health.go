@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// HealthInterface defines the catalog health the health endpoint needs
+type HealthInterface interface {
+	Health() service.HealthReport
+}
+
+// HealthHandler reports the health of the template catalog
+type HealthHandler struct {
+	health HealthInterface
+}
+
+// NewHealthHandler creates a handler for GET /healthz
+func NewHealthHandler(health HealthInterface) *HealthHandler {
+	return &HealthHandler{
+		health: health,
+	}
+}
+
+// ServeHTTP returns the health report. A degraded catalog still answers 200 OK,
+// since the remaining templates are served normally.
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.health.Health())
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// maxMemeRequestBytes caps the size of a meme request body
+const maxMemeRequestBytes = 1 << 20
+
+// MemeGeneratorInterface defines the meme generation the memes endpoint needs
+type MemeGeneratorInterface interface {
+	Generate(ctx context.Context, req *service.MemeRequest) (*service.GeneratedMeme, error)
+}
+
+// MemesHandler generates memes with per-caption styles.
+// The shared proto only carries caption text, so styled captions are exposed over HTTP.
+type MemesHandler struct {
+	memes MemeGeneratorInterface
+}
+
+// NewMemesHandler creates a handler for POST /v1/memes
+func NewMemesHandler(memes MemeGeneratorInterface) *MemesHandler {
+	return &MemesHandler{
+		memes: memes,
+	}
+}
+
+// ServeHTTP generates the meme described by the JSON request body
+func (h *MemesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req service.MemeRequest
+	if err := decodeJSON(http.MaxBytesReader(w, r.Body, maxMemeRequestBytes), &req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid meme request: %v", err))
+		return
+	}
+	if req.TemplateID == "" {
+		writeError(w, http.StatusBadRequest, "template_id is required")
+		return
+	}
+
+	meme, err := h.memes.Generate(r.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTemplateNotFound), errors.Is(err, service.ErrVersionNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrInvalidCaption), errors.Is(err, service.ErrInvalidLayout):
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrCaptionTooLong):
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+		default:
+			log.Printf("Error generating meme: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to generate meme")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, meme)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// TemplatePreviewInterface defines the preview operation the preview endpoint needs
+type TemplatePreviewInterface interface {
+	TemplatePreview(filename, size string, captions bool) ([]byte, error)
+}
+
+// PreviewHandler serves template thumbnails at the preview URLs returned by ListTemplates
+type PreviewHandler struct {
+	previews TemplatePreviewInterface
+}
+
+// NewPreviewHandler creates a handler for GET /templates/{filename}
+func NewPreviewHandler(previews TemplatePreviewInterface) *PreviewHandler {
+	return &PreviewHandler{
+		previews: previews,
+	}
+}
+
+// ServeHTTP returns a thumbnail in the requested size, optionally with placeholder captions
+func (h *PreviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	captions := false
+	if value := query.Get("captions"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "captions must be true or false")
+			return
+		}
+		captions = parsed
+	}
+
+	data, err := h.previews.TemplatePreview(r.PathValue("filename"), query.Get("size"), captions)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTemplateNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrUnknownPreviewSize):
+			writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("Error rendering preview: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to render preview")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(data)
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// UsageStatsInterface defines the usage statistics the stats endpoint needs
+type UsageStatsInterface interface {
+	UsageStats() []service.TemplateUsage
+}
+
+// StatsHandler serves per-template usage statistics.
+// The shared gRPC contract has no stats RPC, so the statistics are exposed over HTTP.
+type StatsHandler struct {
+	stats UsageStatsInterface
+}
+
+// usageStatsResponse is the JSON form of the usage statistics
+type usageStatsResponse struct {
+	Templates []service.TemplateUsage `json:"templates"`
+}
+
+// NewStatsHandler creates a handler for GET /v1/stats
+func NewStatsHandler(stats UsageStatsInterface) *StatsHandler {
+	return &StatsHandler{
+		stats: stats,
+	}
+}
+
+// ServeHTTP returns the usage of every template, most used first
+func (h *StatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, usageStatsResponse{Templates: h.stats.UsageStats()})
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// TemplateSearchInterface defines the catalog search the template listing endpoint needs
+type TemplateSearchInterface interface {
+	SearchTemplates(query *service.TemplateQuery) (*service.TemplatePage, error)
+}
+
+// TemplatesHandler serves the paginated template listing.
+// It complements ListTemplates, whose messages have no paging or search fields.
+type TemplatesHandler struct {
+	templates TemplateSearchInterface
+}
+
+// templateResponse is the JSON form of a template in listings
+type templateResponse struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Category       string    `json:"category"`
+	Categories     []string  `json:"categories"`
+	Tags           []string  `json:"tags,omitempty"`
+	Aliases        []string  `json:"aliases,omitempty"`
+	TextFieldCount int32     `json:"text_field_count"`
+	Version        string    `json:"version"`
+	PreviewURL     string    `json:"preview_url"`
+	Keywords       []string  `json:"keywords,omitempty"`
+	AddedAt        time.Time `json:"added_at"`
+}
+
+// listTemplatesResponse is the JSON form of a page of templates
+type listTemplatesResponse struct {
+	Templates     []templateResponse `json:"templates"`
+	NextPageToken string             `json:"next_page_token,omitempty"`
+	TotalSize     int                `json:"total_size"`
+}
+
+// NewTemplatesHandler creates a handler for GET /v1/templates
+func NewTemplatesHandler(templates TemplateSearchInterface) *TemplatesHandler {
+	return &TemplatesHandler{
+		templates: templates,
+	}
+}
+
+// ServeHTTP lists templates filtered by category, tag and q, ordered by sort and paged with
+// page_size and page_token
+func (h *TemplatesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	query := &service.TemplateQuery{
+		Category:  params.Get("category"),
+		Tag:       params.Get("tag"),
+		Text:      params.Get("q"),
+		SortBy:    params.Get("sort"),
+		PageToken: params.Get("page_token"),
+	}
+	if value := params.Get("page_size"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "page_size must be an integer")
+			return
+		}
+		query.PageSize = pageSize
+	}
+
+	log.Printf("Handler: Searching templates, category: %q, tag: %q, query: %q, sort: %q", query.Category, query.Tag, query.Text, query.SortBy)
+
+	page, err := h.templates.SearchTemplates(query)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidQuery) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("Error searching templates: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to search templates")
+		return
+	}
+
+	response := listTemplatesResponse{
+		Templates:     make([]templateResponse, 0, len(page.Templates)),
+		NextPageToken: page.NextPageToken,
+		TotalSize:     page.TotalSize,
+	}
+	for _, info := range page.Templates {
+		response.Templates = append(response.Templates, templateResponse{
+			ID:             info.ID,
+			Name:           info.Name,
+			Category:       info.Category,
+			Categories:     info.AllCategories(),
+			Tags:           info.Tags,
+			Aliases:        info.Aliases,
+			TextFieldCount: info.TextFieldCount,
+			Version:        info.Version,
+			PreviewURL:     info.PreviewURL(),
+			Keywords:       info.Keywords,
+			AddedAt:        info.AddedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
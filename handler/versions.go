@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// TemplateVersionsInterface defines the version history the versions endpoint needs
+type TemplateVersionsInterface interface {
+	TemplateVersions(id string) ([]service.TemplateVersion, error)
+}
+
+// VersionsHandler lists the versions of a template that can be pinned in GenerateMeme
+type VersionsHandler struct {
+	versions TemplateVersionsInterface
+}
+
+// templateVersionsResponse is the JSON form of a template's version history
+type templateVersionsResponse struct {
+	ID       string                    `json:"id"`
+	Versions []service.TemplateVersion `json:"versions"`
+}
+
+// NewVersionsHandler creates a handler for GET /v1/templates/{id}/versions
+func NewVersionsHandler(versions TemplateVersionsInterface) *VersionsHandler {
+	return &VersionsHandler{
+		versions: versions,
+	}
+}
+
+// ServeHTTP lists the versions of the template named in the path, newest first
+func (h *VersionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	versions, err := h.versions.TemplateVersions(id)
+	if err != nil {
+		if errors.Is(err, service.ErrTemplateNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("Error listing template versions: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list template versions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, templateVersionsResponse{ID: id, Versions: versions})
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+)
+
+// NewHTTPServer creates the HTTP server that hosts the endpoints not covered by the gRPC contract
+func NewHTTPServer(memeService *service.MemeService, cfg *config.Config) *http.Server {
+	mux := http.NewServeMux()
+
+	// Template management: create, update, replace image and delete
+	mux.Handle("/admin/", handler.NewAdminHandler(memeService, cfg.AdminToken))
+
+	// Meme generation with per-caption styles
+	mux.Handle("POST /v1/memes", handler.NewMemesHandler(memeService))
+
+	// Paginated, searchable template listing
+	mux.Handle("GET /v1/templates", handler.NewTemplatesHandler(memeService))
+
+	// Archived template versions that GenerateMeme can be pinned to
+	mux.Handle("GET /v1/templates/{id}/versions", handler.NewVersionsHandler(memeService))
+
+	// Per-template usage statistics
+	mux.Handle("GET /v1/stats", handler.NewStatsHandler(memeService))
+
+	// Catalog health, including templates quarantined by checksum verification
+	mux.Handle("GET /healthz", handler.NewHealthHandler(memeService))
+
+	// Thumbnails for the preview URLs returned by ListTemplates
+	mux.Handle("GET /templates/{filename}", handler.NewPreviewHandler(memeService))
+
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.HTTPPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// StartHTTPServer serves HTTP requests until the server is shut down
+func StartHTTPServer(server *http.Server) error {
+	log.Printf("HTTP endpoints listening on %s", server.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("HTTP server failed: %v", err)
+	}
+	return nil
+}
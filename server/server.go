@@ -3,9 +3,11 @@ package server
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/RoMalms10/meme-generator/config"
 	"github.com/RoMalms10/meme-generator/service"
@@ -15,6 +17,7 @@ import (
 // Server encapsulates the gRPC server and services
 type Server struct {
 	grpcServer  *grpc.Server
+	httpServer  *http.Server
 	memeService *service.MemeService
 	config      *config.Config
 }
@@ -27,8 +30,15 @@ func NewServer(cfg *config.Config) *Server {
 	// Create and configure the gRPC server
 	grpcServer := NewGRPCServer(memeService, cfg)
 
+	// The HTTP endpoints are optional and disabled when no port is configured
+	var httpServer *http.Server
+	if cfg.HTTPPort != "" {
+		httpServer = NewHTTPServer(memeService, cfg)
+	}
+
 	return &Server{
 		grpcServer:  grpcServer,
+		httpServer:  httpServer,
 		memeService: memeService,
 		config:      cfg,
 	}
@@ -44,14 +54,27 @@ func (s *Server) Start() error {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 
-	// Start the server in a goroutine
-	errChan := make(chan error, 1)
+	// Start the servers in goroutines
+	errChan := make(chan error, 2)
 	go func() {
 		errChan <- StartServer(s.grpcServer, s.config.Port)
 	}()
+	if s.httpServer != nil {
+		go func() {
+			if err := StartHTTPServer(s.httpServer); err != nil {
+				errChan <- err
+			}
+		}()
+	}
 
 	// Server is already configured with all services in NewGRPCServer
 
+	// Pick up template changes without a restart
+	go s.memeService.WatchTemplates(ctx, s.config.TemplateReloadInterval)
+
+	// Periodically save usage statistics so a crash loses at most one interval
+	go s.memeService.PersistUsageStats(ctx, s.config.StatsFlushInterval)
+
 	// Wait for termination signal or server error
 	select {
 	case err := <-errChan:
@@ -69,11 +92,27 @@ func (s *Server) Start() error {
 
 // Stop gracefully shuts down the server
 func (s *Server) Stop() {
+	if s.httpServer != nil {
+		log.Println("Stopping HTTP server...")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.httpServer.Shutdown(ctx); err != nil {
+			log.Printf("HTTP server shutdown failed: %v", err)
+		}
+	}
+
 	if s.grpcServer != nil {
 		log.Println("Stopping gRPC server gracefully...")
 		s.grpcServer.GracefulStop()
 		log.Println("Server stopped")
 	}
+
+	// Save the usage recorded since the last periodic flush
+	if s.memeService != nil {
+		if err := s.memeService.SaveUsageStats(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
 }
 
 // GetMemeService returns the meme service instance for testing or configuration
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Arabic letters change shape with the letters they join. Fonts are drawn glyph by
+// glyph, without OpenType shaping, so captions are shaped beforehand by swapping each
+// letter for its contextual form from the Arabic Presentation Forms blocks, which fonts
+// with Arabic support include.
+
+// arabicForms are the isolated, final, initial and medial forms of a letter. Letters
+// that only join the letter before them, on their right, have no initial or medial form.
+type arabicForms [4]rune
+
+const (
+	formIsolated = iota
+	formFinal
+	formInitial
+	formMedial
+)
+
+// dualJoining reports whether the letter joins on both sides
+func (f arabicForms) dualJoining() bool {
+	return f[formInitial] != 0
+}
+
+// arabicLetters maps the Arabic and Persian letters to their contextual forms
+var arabicLetters = map[rune]arabicForms{
+	'ء': {0xFE80, 0, 0, 0}, // Hamza, which joins neither side
+	'آ': {0xFE81, 0xFE82, 0, 0},
+	'أ': {0xFE83, 0xFE84, 0, 0},
+	'ؤ': {0xFE85, 0xFE86, 0, 0},
+	'إ': {0xFE87, 0xFE88, 0, 0},
+	'ئ': {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	'ا': {0xFE8D, 0xFE8E, 0, 0},
+	'ب': {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	'ة': {0xFE93, 0xFE94, 0, 0},
+	'ت': {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	'ث': {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	'ج': {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	'ح': {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	'خ': {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	'د': {0xFEA9, 0xFEAA, 0, 0},
+	'ذ': {0xFEAB, 0xFEAC, 0, 0},
+	'ر': {0xFEAD, 0xFEAE, 0, 0},
+	'ز': {0xFEAF, 0xFEB0, 0, 0},
+	'س': {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	'ش': {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	'ص': {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	'ض': {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	'ط': {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	'ظ': {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	'ع': {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	'غ': {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	'ف': {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	'ق': {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	'ك': {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	'ل': {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	'م': {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	'ن': {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	'ه': {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	'و': {0xFEED, 0xFEEE, 0, 0},
+	'ى': {0xFEEF, 0xFEF0, 0, 0},
+	'ي': {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+	'پ': {0xFB56, 0xFB57, 0xFB58, 0xFB59}, // Peh
+	'چ': {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D}, // Tcheh
+	'ژ': {0xFB8A, 0xFB8B, 0, 0},           // Jeh
+	'ک': {0xFB8E, 0xFB8F, 0xFB90, 0xFB91}, // Keheh
+	'گ': {0xFB92, 0xFB93, 0xFB94, 0xFB95}, // Gaf
+	'ی': {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF}, // Farsi yeh
+}
+
+// arabicTatweel stretches the joins around it and joins on both sides
+const arabicTatweel = 'ـ'
+
+// lamAlefLigatures maps the alefs that form a required ligature after lam to the
+// isolated and final forms of the ligature
+var lamAlefLigatures = map[rune][2]rune{
+	'آ': {0xFEF5, 0xFEF6},
+	'أ': {0xFEF7, 0xFEF8},
+	'إ': {0xFEF9, 0xFEFA},
+	'ا': {0xFEFB, 0xFEFC},
+}
+
+// shapeArabic replaces the Arabic letters of text with their contextual forms. Forms the
+// font has no glyph for are left as the plain letter, so text still renders with fonts
+// that lack the presentation forms.
+func shapeArabic(text string, hasGlyph func(rune) bool) string {
+	if !strings.ContainsFunc(text, func(r rune) bool { _, ok := arabicLetters[r]; return ok }) {
+		return text
+	}
+
+	runes := []rune(text)
+	shaped := make([]rune, 0, len(runes))
+
+	// neighbour returns the position of the next letter from i in direction step,
+	// skipping the marks in between, or -1
+	neighbour := func(i, step int) int {
+		for i += step; i >= 0 && i < len(runes); i += step {
+			if !unicode.Is(unicode.Mn, runes[i]) {
+				return i
+			}
+		}
+		return -1
+	}
+	// joinsForward reports whether the letter at i joins the letter after it
+	joinsForward := func(i int) bool {
+		if i < 0 {
+			return false
+		}
+		forms, ok := arabicLetters[runes[i]]
+		return runes[i] == arabicTatweel || (ok && forms.dualJoining())
+	}
+	// joinsBackward reports whether the letter at i joins the letter before it
+	joinsBackward := func(i int) bool {
+		if i < 0 {
+			return false
+		}
+		forms, ok := arabicLetters[runes[i]]
+		return runes[i] == arabicTatweel || (ok && forms[formFinal] != 0)
+	}
+	// pick returns the wanted form, or the letter itself when the font lacks it
+	pick := func(letter, form rune) rune {
+		if form == 0 || !hasGlyph(form) {
+			return letter
+		}
+		return form
+	}
+
+	for i := 0; i < len(runes); i++ {
+		letter := runes[i]
+		forms, ok := arabicLetters[letter]
+		if !ok {
+			shaped = append(shaped, letter)
+			continue
+		}
+		prev, next := neighbour(i, -1), neighbour(i, 1)
+		joinedBefore := joinsForward(prev) && forms[formFinal] != 0
+
+		// Lam and alef are always drawn as one ligature; marks between them follow it
+		if ligature, ok := lamAlefLigatures[runeAt(runes, next)]; ok && letter == 'ل' {
+			form := ligature[formIsolated]
+			if joinedBefore {
+				form = ligature[formFinal]
+			}
+			if hasGlyph(form) {
+				shaped = append(shaped, form)
+				shaped = append(shaped, runes[i+1:next]...)
+				i = next
+				continue
+			}
+		}
+
+		joinedAfter := forms.dualJoining() && joinsBackward(next)
+		switch {
+		case joinedBefore && joinedAfter:
+			shaped = append(shaped, pick(letter, forms[formMedial]))
+		case joinedBefore:
+			shaped = append(shaped, pick(letter, forms[formFinal]))
+		case joinedAfter:
+			shaped = append(shaped, pick(letter, forms[formInitial]))
+		default:
+			shaped = append(shaped, pick(letter, forms[formIsolated]))
+		}
+	}
+
+	return string(shaped)
+}
+
+// runeAt returns runes[i], or -1 when i is out of range
+func runeAt(runes []rune, i int) rune {
+	if i < 0 || i >= len(runes) {
+		return -1
+	}
+	return runes[i]
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"golang.org/x/text/unicode/bidi"
+)
+
+// Lines are reordered for display with the implicit rules of the Unicode Bidirectional
+// Algorithm (UAX #9): the character classes come from golang.org/x/text, which does not
+// expose resolved levels or visual order. Explicit embeddings, overrides and isolates
+// are not supported; those formatting characters count as neutrals.
+
+// paragraphRTL reports whether a paragraph is right-to-left: whether its first strong
+// character is right-to-left (rules P2 and P3)
+func paragraphRTL(paragraph string) bool {
+	for _, r := range paragraph {
+		switch bidiClass(r) {
+		case bidi.L:
+			return false
+		case bidi.R, bidi.AL:
+			return true
+		}
+	}
+	return false
+}
+
+// bidiClass returns the bidirectional class of r. Emoji runes, which stand for emoji
+// that are neutral, count as neutrals, as do the formatting characters not supported.
+func bidiClass(r rune) bidi.Class {
+	if isEmojiRune(r) {
+		return bidi.ON
+	}
+
+	props, _ := bidi.LookupRune(r)
+	switch class := props.Class(); class {
+	case bidi.L, bidi.R, bidi.EN, bidi.ES, bidi.ET, bidi.AN, bidi.CS, bidi.S, bidi.WS, bidi.NSM, bidi.AL:
+		return class
+	default:
+		return bidi.ON
+	}
+}
+
+// needsReordering reports whether a line of a paragraph can look different in visual order
+func needsReordering(line string, rtl bool) bool {
+	if rtl {
+		return true
+	}
+	for _, r := range line {
+		switch bidiClass(r) {
+		case bidi.R, bidi.AL, bidi.AN:
+			return true
+		}
+	}
+	return false
+}
+
+// visualOrder returns a line of a paragraph in the order it is drawn, left to right.
+// Right-to-left runs are reversed with their brackets mirrored, while combining marks
+// stay after the character they belong to.
+func visualOrder(line string, rtl bool) string {
+	if !needsReordering(line, rtl) {
+		return line
+	}
+
+	runes := []rune(line)
+	classes := make([]bidi.Class, len(runes))
+	for i, r := range runes {
+		classes[i] = bidiClass(r)
+	}
+	levels := resolveLevels(classes, rtl)
+
+	// Reorder whole characters: a character and the marks after it
+	type cluster struct {
+		runes []rune
+		level uint8
+	}
+	var clusters []cluster
+	for i, r := range runes {
+		if classes[i] == bidi.NSM && len(clusters) > 0 {
+			clusters[len(clusters)-1].runes = append(clusters[len(clusters)-1].runes, r)
+			continue
+		}
+		clusters = append(clusters, cluster{runes: []rune{r}, level: levels[i]})
+	}
+
+	// Rule L2: from the highest level down to the lowest odd level, reverse every run of
+	// clusters at that level or higher
+	highest, lowestOdd := uint8(0), uint8(255)
+	for _, c := range clusters {
+		highest = max(highest, c.level)
+		if c.level%2 == 1 {
+			lowestOdd = min(lowestOdd, c.level)
+		}
+	}
+	for level := highest; level >= lowestOdd && level > 0; level-- {
+		for start := 0; start < len(clusters); {
+			if clusters[start].level < level {
+				start++
+				continue
+			}
+			end := start
+			for end < len(clusters) && clusters[end].level >= level {
+				end++
+			}
+			for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+				clusters[i], clusters[j] = clusters[j], clusters[i]
+			}
+			start = end
+		}
+	}
+
+	// Rule L4: right-to-left brackets are drawn mirrored
+	visual := make([]rune, 0, len(runes))
+	for _, c := range clusters {
+		if props, _ := bidi.LookupRune(c.runes[0]); c.level%2 == 1 && props.IsBracket() {
+			c.runes[0] = []rune(bidi.ReverseString(string(c.runes[0])))[0]
+		}
+		visual = append(visual, c.runes...)
+	}
+	return string(visual)
+}
+
+// resolveLevels resolves the embedding level of every character of a line (rules W1 to
+// W7, N1, N2, I1, I2 and the trailing whitespace part of L1)
+func resolveLevels(classes []bidi.Class, rtl bool) []uint8 {
+	classes = append([]bidi.Class(nil), classes...)
+	n := len(classes)
+	base, sos := uint8(0), bidi.L
+	if rtl {
+		base, sos = 1, bidi.R
+	}
+	trailing := n
+	for trailing > 0 && (classes[trailing-1] == bidi.WS || classes[trailing-1] == bidi.S) {
+		trailing--
+	}
+
+	// W1: marks take the class of the character before them
+	for i, class := range classes {
+		if class == bidi.NSM {
+			if i == 0 {
+				classes[i] = sos
+			} else {
+				classes[i] = classes[i-1]
+			}
+		}
+	}
+
+	// W2 and W3: numbers after Arabic letters are Arabic numbers, and Arabic letters are
+	// right-to-left
+	strong := sos
+	for i, class := range classes {
+		switch class {
+		case bidi.L, bidi.R, bidi.AL:
+			strong = class
+		case bidi.EN:
+			if strong == bidi.AL {
+				classes[i] = bidi.AN
+			}
+		}
+	}
+	for i, class := range classes {
+		if class == bidi.AL {
+			classes[i] = bidi.R
+		}
+	}
+
+	// W4: a single separator between two numbers of the same kind joins them
+	for i := 1; i+1 < n; i++ {
+		prev, next := classes[i-1], classes[i+1]
+		switch {
+		case classes[i] == bidi.ES && prev == bidi.EN && next == bidi.EN:
+			classes[i] = bidi.EN
+		case classes[i] == bidi.CS && prev == next && (prev == bidi.EN || prev == bidi.AN):
+			classes[i] = prev
+		}
+	}
+
+	// W5: terminators next to European numbers, like currency and percent signs, belong to them
+	for start := 0; start < n; {
+		if classes[start] != bidi.ET {
+			start++
+			continue
+		}
+		end := start
+		for end < n && classes[end] == bidi.ET {
+			end++
+		}
+		if (start > 0 && classes[start-1] == bidi.EN) || (end < n && classes[end] == bidi.EN) {
+			for i := start; i < end; i++ {
+				classes[i] = bidi.EN
+			}
+		}
+		start = end
+	}
+
+	// W6 and W7: other separators are neutral, and European numbers after left-to-right
+	// text are left-to-right
+	strong = sos
+	for i, class := range classes {
+		switch class {
+		case bidi.ES, bidi.ET, bidi.CS:
+			classes[i] = bidi.ON
+		case bidi.L, bidi.R:
+			strong = class
+		case bidi.EN:
+			if strong == bidi.L {
+				classes[i] = bidi.L
+			}
+		}
+	}
+
+	// N1 and N2: neutrals between characters of the same direction take that direction,
+	// others the paragraph's
+	direction := func(class bidi.Class) bidi.Class {
+		if class == bidi.L {
+			return bidi.L
+		}
+		return bidi.R
+	}
+	for start := 0; start < n; {
+		if !isNeutral(classes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < n && isNeutral(classes[end]) {
+			end++
+		}
+		before, after := sos, sos
+		if start > 0 {
+			before = direction(classes[start-1])
+		}
+		if end < n {
+			after = direction(classes[end])
+		}
+		resolved := sos
+		if before == after {
+			resolved = before
+		}
+		for i := start; i < end; i++ {
+			classes[i] = resolved
+		}
+		start = end
+	}
+
+	// I1 and I2
+	levels := make([]uint8, n)
+	for i, class := range classes {
+		levels[i] = base
+		switch {
+		case base == 0 && class == bidi.R:
+			levels[i] = 1
+		case base == 0 && (class == bidi.AN || class == bidi.EN):
+			levels[i] = 2
+		case base == 1 && class != bidi.R:
+			levels[i] = 2
+		}
+	}
+
+	// L1: whitespace at the end of the line takes the paragraph level
+	for i := trailing; i < n; i++ {
+		levels[i] = base
+	}
+
+	return levels
+}
+
+// isNeutral reports whether a resolved class is neutral
+func isNeutral(class bidi.Class) bool {
+	return class == bidi.ON || class == bidi.WS || class == bidi.S
+}
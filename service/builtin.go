@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/RoMalms10/meme-generator/assets"
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+)
+
+// ErrBuiltinTemplates is returned when changing the catalog while it is the built-in template pack
+var ErrBuiltinTemplates = errors.New("the built-in template pack cannot be changed")
+
+// The built-in font and its fallback are parsed once and shared by every service
+var (
+	builtinFontOnce sync.Once
+	builtinFont     *truetype.Font
+	builtinFallback *truetype.Font
+	builtinFontErr  error
+)
+
+// loadCatalog loads the templates in dir, falling back to the built-in pack when dir does
+// not exist or has no manifest, such as a freshly mounted empty volume
+func loadCatalog(dir string) (map[string]*TemplateInfo, error) {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Template directory %s not found, using the built-in template pack", dir)
+		return loadBuiltinTemplates()
+	}
+	if _, err := os.Stat(filepath.Join(dir, ManifestFilename)); errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Template directory %s has no %s, using the built-in template pack", dir, ManifestFilename)
+		return loadBuiltinTemplates()
+	}
+
+	return LoadTemplates(dir)
+}
+
+// loadBuiltinTemplates loads the template pack embedded in the binary
+func loadBuiltinTemplates() (map[string]*TemplateInfo, error) {
+	pack := assets.Templates()
+
+	templates, err := loadTemplatesFS(pack, "built-in templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range templates {
+		info.images = pack
+	}
+
+	return templates, nil
+}
+
+// templateImages returns the file system holding the image of template
+func (s *MemeService) templateImages(template *TemplateInfo) fs.FS {
+	if template.images != nil {
+		return template.images
+	}
+	return os.DirFS(s.Config.TemplateDir)
+}
+
+// checkWritable rejects changes while the catalog is the built-in pack, which lives in the binary
+func (s *MemeService) checkWritable() error {
+	// The built-in pack is loaded as a whole, so checking one entry is enough
+	for _, info := range s.TemplateCatalog() {
+		if info.images != nil {
+			return fmt.Errorf("%w: create %s to manage templates", ErrBuiltinTemplates, filepath.Join(s.Config.TemplateDir, ManifestFilename))
+		}
+		break
+	}
+	return nil
+}
+
+// loadFont parses the configured font file, falling back to the built-in font when the file does not exist
+func (s *MemeService) loadFont() (*truetype.Font, error) {
+	fontData, err := os.ReadFile(s.Config.FontFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.fontFallbackOnce.Do(func() {
+			log.Printf("Font file %s not found, using the built-in %s font", s.Config.FontFile, assets.FontName)
+		})
+		return parseBuiltinFont()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %v", err)
+	}
+
+	f, err := freetype.ParseFont(fontData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %v", err)
+	}
+
+	return f, nil
+}
+
+// parseBuiltinFont returns the font embedded in the binary
+func parseBuiltinFont() (*truetype.Font, error) {
+	builtinFontOnce.Do(func() {
+		builtinFont, builtinFontErr = freetype.ParseFont(assets.Font())
+		if builtinFontErr == nil {
+			builtinFallback, builtinFontErr = freetype.ParseFont(assets.FallbackFont())
+		}
+		if builtinFontErr != nil {
+			builtinFontErr = fmt.Errorf("failed to parse built-in font: %v", builtinFontErr)
+		}
+	})
+	return builtinFont, builtinFontErr
+}
+
+// builtinFallbackFor returns the font embedded for the characters f has no glyph for
+// when f is the built-in font
+func builtinFallbackFor(f *truetype.Font) (*truetype.Font, bool) {
+	builtin, err := parseBuiltinFont()
+	if err != nil || f != builtin {
+		return nil, false
+	}
+	return builtinFallback, true
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/golang/freetype/truetype"
+)
+
+// Layouts a meme request can ask for
+const (
+	LayoutOverlay    = "overlay"     // Captions drawn over the template image (default)
+	LayoutCaptionBar = "caption-bar" // Captions on bars above and below the image, which extend the canvas
+)
+
+// ErrInvalidLayout is returned for meme requests with malformed layout options
+var ErrInvalidLayout = errors.New("invalid layout")
+
+// CaptionBar configures the caption-bar layout. Unset fields keep the modern meme style:
+// black text without an outline on a white bar.
+type CaptionBar struct {
+	Color     string     `json:"color,omitempty"`      // Bar colour, same format as TextStyle.Color
+	TextStyle *TextStyle `json:"text_style,omitempty"` // Text style of both bars; a caption's own style takes precedence
+}
+
+// defaultBarRenderStyle is the modern caption-bar style: centred black text without an outline
+var defaultBarRenderStyle = renderStyle{
+	fill:        color.Black,
+	stroke:      color.White,
+	strokeWidth: 0,
+	align:       AlignCenter,
+}
+
+// validateLayout checks the layout options of a meme request. The caption-bar layout
+// only has a bar for the top text and one for the bottom text, so captions for other text
+// fields are rejected rather than dropped.
+func (s *MemeService) validateLayout(req *MemeRequest) error {
+	switch req.Layout {
+	case "", LayoutOverlay:
+		if req.CaptionBar != nil {
+			return fmt.Errorf("%w: caption_bar needs the %s layout", ErrInvalidLayout, LayoutCaptionBar)
+		}
+		return nil
+	case LayoutCaptionBar:
+	default:
+		return fmt.Errorf("%w: layout must be %s or %s", ErrInvalidLayout, LayoutOverlay, LayoutCaptionBar)
+	}
+
+	for i := 2; i < len(req.Captions); i++ {
+		if req.Captions[i].Text != "" {
+			return fmt.Errorf("%w: captions[%d]: the %s layout only has top and bottom text", ErrInvalidLayout, i, LayoutCaptionBar)
+		}
+	}
+
+	bar := req.CaptionBar
+	if bar == nil {
+		return nil
+	}
+	if bar.Color != "" {
+		if _, err := parseColor(bar.Color); err != nil {
+			return fmt.Errorf("%w: caption_bar: %v", ErrInvalidLayout, err)
+		}
+	}
+	if problems := validateTextStyle("caption_bar.text_style", bar.TextStyle); len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidLayout, problems[0])
+	}
+	if bar.TextStyle != nil && bar.TextStyle.Font != "" {
+		if _, err := s.loadNamedFont(bar.TextStyle.Font); err != nil {
+			return fmt.Errorf("%w: caption_bar.text_style: unknown font %s", ErrInvalidLayout, bar.TextStyle.Font)
+		}
+	}
+	return nil
+}
+
+// barFont returns the font of the caption bars: the one their text style names, or the
+// configured font. The template's own text style is made for text over the image, so
+// it does not apply.
+func (s *MemeService) barFont(bar *CaptionBar) (*truetype.Font, error) {
+	if bar == nil || bar.TextStyle == nil || bar.TextStyle.Font == "" {
+		return s.loadFont()
+	}
+	f, err := s.loadNamedFont(bar.TextStyle.Font)
+	if err != nil {
+		return nil, fmt.Errorf("%w: caption_bar.text_style: unknown font %s", ErrInvalidLayout, bar.TextStyle.Font)
+	}
+	return f, nil
+}
+
+// renderCaptionBars draws the top text on a bar above the template image and the bottom
+// text on a bar below it. Each bar is as tall as its wrapped caption plus padding, and
+// there is no bar for a caption without text, so the meme is taller than the template.
+// Captions are fitted as they are over the image, in a box as tall as the image, so the
+// overflow policy still bounds the bars. Text regions are not used.
+func (s *MemeService) renderCaptionBars(template *TemplateInfo, captions []Caption, bar *CaptionBar) (*image.RGBA, error) {
+	img, err := s.loadTemplateImage(template)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+
+	f, err := s.barFont(bar)
+	if err != nil {
+		return nil, err
+	}
+	style := defaultBarRenderStyle
+	barColor := color.Color(color.White)
+	if bar != nil {
+		style = style.apply(bar.TextStyle)
+		if c, err := parseColor(bar.Color); err == nil {
+			barColor = c
+		}
+	}
+
+	// Captions start from the size they would have over the image
+	minSize, maxSize := s.fontSizeLimits()
+	fontSize := min(max(float64(bounds.Dx())/12, minSize), maxSize)
+
+	// fitBar lays out caption i for its bar and returns the height of the bar, or nil
+	// and 0 when the caption has no text
+	type fittedBar struct {
+		fit     *fittedCaption
+		caption styledCaption
+		margin  int // Space above and below the text
+	}
+	fitBar := func(i int, name string) (*fittedBar, int, error) {
+		if captionText(captions, i) == "" {
+			return nil, 0, nil
+		}
+		caption, err := s.styleCaption(captions[i], style, f)
+		if err != nil {
+			return nil, 0, err
+		}
+		fit, err := s.fitCaption(caption, captionBox{name: name, width: bounds.Dx(), height: bounds.Dy(), size: fontSize})
+		if err != nil {
+			return nil, 0, err
+		}
+		margin := int(fit.size / 2)
+		return &fittedBar{fit: fit, caption: caption, margin: margin}, fit.layout.Height + 2*margin, nil
+	}
+
+	top, topHeight, err := fitBar(0, "top text")
+	if err != nil {
+		return nil, err
+	}
+	bottom, bottomHeight, err := fitBar(1, "bottom text")
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill the canvas with the bar colour and put the template image between the bars
+	memeImg := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), topHeight+bounds.Dy()+bottomHeight))
+	draw.Draw(memeImg, memeImg.Bounds(), image.NewUniform(barColor), image.Point{}, draw.Src)
+	draw.Draw(memeImg, bounds.Sub(bounds.Min).Add(image.Pt(0, topHeight)), img, bounds.Min, draw.Src)
+
+	if top != nil {
+		drawTextWithStroke(memeImg, top.fit.face, top.fit.layout, image.Pt(top.fit.padding, top.margin), top.caption.style, top.fit.size)
+	}
+	if bottom != nil {
+		origin := image.Pt(bottom.fit.padding, topHeight+bounds.Dy()+bottom.margin)
+		drawTextWithStroke(memeImg, bottom.fit.face, bottom.fit.layout, origin, bottom.caption.style, bottom.fit.size)
+	}
+
+	return memeImg, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+)
+
+// MaxCaptionFontSize is the largest explicit font size a caption may request, in points
+const MaxCaptionFontSize = 200
+
+// ErrInvalidCaption is returned for captions with malformed style options
+var ErrInvalidCaption = errors.New("invalid caption")
+
+// MemeRequest asks for a meme with per-caption options. It is the richer form of
+// pb.GenerateMemeRequest, whose shared proto only carries the caption text.
+type MemeRequest struct {
+	TemplateID      string    `json:"template_id"`
+	TemplateVersion string    `json:"template_version,omitempty"` // Empty renders the current version
+	Captions        []Caption `json:"captions"`                   // Top text, bottom text, then additional text
+	UseAICaption    bool      `json:"use_ai_caption,omitempty"`
+
+	Layout     string      `json:"layout,omitempty"`      // LayoutOverlay (default) or LayoutCaptionBar
+	CaptionBar *CaptionBar `json:"caption_bar,omitempty"` // Options of the caption-bar layout
+}
+
+// Caption is the text of one text field and how to draw it
+type Caption struct {
+	Text  string        `json:"text"`
+	Style *CaptionStyle `json:"style,omitempty"`
+}
+
+// CaptionStyle overrides the template text style for one caption. Unset fields keep the
+// template style, so a caption without a style looks as it always has.
+type CaptionStyle struct {
+	TextStyle
+	Size *float64 `json:"size,omitempty"` // Font size in points; unset uses the default size, shrunk to fit when AUTO_FIT_TEXT is on
+}
+
+// GeneratedMeme is a rendered meme
+type GeneratedMeme struct {
+	ImageData         []byte   `json:"image_data"`
+	MimeType          string   `json:"mime_type"`
+	TemplateID        string   `json:"template_id"`
+	TemplateVersion   string   `json:"template_version"`
+	Width             int      `json:"width"`  // Size of the image, which the caption-bar layout makes
+	Height            int      `json:"height"` // taller than the template
+	GeneratedCaptions []string `json:"generated_captions,omitempty"`
+}
+
+// captionsFromText turns the caption text of a pb.GenerateMemeRequest into captions
+func captionsFromText(topText, bottomText string, additionalText []string) []Caption {
+	captions := []Caption{{Text: topText}, {Text: bottomText}}
+	for _, text := range additionalText {
+		captions = append(captions, Caption{Text: text})
+	}
+	return captions
+}
+
+// captionText returns the text of caption i, or an empty string when there is none
+func captionText(captions []Caption, i int) string {
+	if i < len(captions) {
+		return captions[i].Text
+	}
+	return ""
+}
+
+// validateCaptions checks the style options of every caption, including that the fonts
+// they name can be loaded
+func (s *MemeService) validateCaptions(captions []Caption) error {
+	for i, caption := range captions {
+		if caption.Style == nil {
+			continue
+		}
+		ref := fmt.Sprintf("captions[%d].style", i)
+
+		if problems := validateTextStyle(ref, &caption.Style.TextStyle); len(problems) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidCaption, problems[0])
+		}
+		if size := caption.Style.Size; size != nil && (*size <= 0 || *size > MaxCaptionFontSize) {
+			return fmt.Errorf("%w: %s: size must be greater than 0 and at most %d", ErrInvalidCaption, ref, MaxCaptionFontSize)
+		}
+		if caption.Style.Font != "" {
+			if _, err := s.loadNamedFont(caption.Style.Font); err != nil {
+				return fmt.Errorf("%w: %s: unknown font %s", ErrInvalidCaption, ref, caption.Style.Font)
+			}
+		}
+	}
+	return nil
+}
+
+// styledCaption is a caption resolved for drawing
+type styledCaption struct {
+	text  string
+	fonts fontChain
+	emoji []image.Image // Sprites of the emoji runes in text
+	style renderStyle
+	size  float64 // Fixed font size; 0 fits the caption to its box
+
+	overflow string // Overflow policy; empty uses TEXT_OVERFLOW
+}
+
+// face returns the face a caption is measured and drawn with at the given size
+func (c styledCaption) face(size float64) font.Face {
+	face := newFontFace(c.fonts, size)
+	if len(c.emoji) > 0 {
+		return &emojiFace{Face: face, sprites: c.emoji, size: size}
+	}
+	return face
+}
+
+// shaped returns the caption with its Arabic letters shaped for the fonts it is drawn with
+func (c styledCaption) shaped() styledCaption {
+	c.text = shapeArabic(c.text, c.fonts.hasGlyph)
+	return c
+}
+
+// styleCaption resolves how a caption is drawn: base, the style of the template or text
+// region, with the caption's own overrides, and the font they select followed by the
+// fallback fonts. Emoji with a sprite are swapped for the runes emojiFace draws them for,
+// and Arabic letters for the forms they take in context.
+func (s *MemeService) styleCaption(caption Caption, base renderStyle, templateFont *truetype.Font) (styledCaption, error) {
+	styled := styledCaption{fonts: s.withFallbacks(templateFont), style: base}
+	styled.text, styled.emoji = s.emojiSet().substitute(caption.Text)
+	if caption.Style == nil {
+		return styled.shaped(), nil
+	}
+
+	styled.style = base.apply(&caption.Style.TextStyle)
+	if caption.Style.Size != nil {
+		styled.size = *caption.Style.Size
+	}
+	if caption.Style.Font != "" {
+		f, err := s.loadNamedFont(caption.Style.Font)
+		if err != nil {
+			return styled, fmt.Errorf("%w: unknown font %s", ErrInvalidCaption, caption.Style.Font)
+		}
+		styled.fonts = s.withFallbacks(f)
+	}
+
+	return styled.shaped(), nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Template returns the template with the given id from the current catalog
+func (s *MemeService) Template(id string) (*TemplateInfo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info, exists := s.Templates[id]
+	return info, exists
+}
+
+// TemplateCatalog returns the current catalog. The returned map is never
+// modified after publication and must be treated as read-only.
+func (s *MemeService) TemplateCatalog() map[string]*TemplateInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Templates
+}
+
+// ReplaceTemplates atomically swaps in a new template catalog
+func (s *MemeService) ReplaceTemplates(templates map[string]*TemplateInfo) {
+	s.mu.Lock()
+	s.Templates = templates
+	s.mu.Unlock()
+
+	s.prunePreviews(templates)
+}
+
+// ReloadTemplates loads the manifest from the template directory and swaps it in,
+// quarantining templates whose image fails verification. On error the current catalog is kept.
+func (s *MemeService) ReloadTemplates() error {
+	templates, err := loadCatalog(s.Config.TemplateDir)
+	if err != nil {
+		return err
+	}
+
+	s.publishCatalog(templates)
+	s.reloadFontsAndEmoji()
+
+	return nil
+}
+
+// reloadFontsAndEmoji drops the font registry and emoji sprites so the next render loads
+// them again, picking up files added to or removed from FONT_DIR and EMOJI_DIR
+func (s *MemeService) reloadFontsAndEmoji() {
+	s.fontsMu.Lock()
+	defer s.fontsMu.Unlock()
+
+	s.fonts = nil
+	s.emoji = nil
+}
+
+// LoadCatalog loads the catalog the way ReloadTemplates does, falling back to the built-in
+// pack and quarantining templates that fail verification, but without archiving their
+// versions. Tools that only read the template directory use it to leave the directory as
+// it was.
+func (s *MemeService) LoadCatalog() error {
+	templates, err := loadCatalog(s.Config.TemplateDir)
+	if err != nil {
+		return err
+	}
+
+	s.installCatalog(splitQuarantined(templates))
+	return nil
+}
+
+// WatchTemplates polls the template directory every interval and reloads the
+// catalog when its contents change. Changes to FONT_DIR and EMOJI_DIR reload the fonts
+// and emoji. It blocks until ctx is canceled.
+func (s *MemeService) WatchTemplates(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		log.Println("Template hot reload disabled")
+		return
+	}
+
+	lastFingerprint, err := fingerprintDir(s.Config.TemplateDir)
+	if err != nil {
+		log.Printf("Warning: failed to scan template directory: %v", err)
+	}
+	lastAssets, err := s.fingerprintFontsAndEmoji()
+	if err != nil {
+		log.Printf("Warning: failed to scan font and emoji directories: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Watching %s, %s and %s for changes every %s", s.Config.TemplateDir, s.Config.FontDir, s.Config.EmojiDir, interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if assets, err := s.fingerprintFontsAndEmoji(); err != nil {
+			log.Printf("Warning: failed to scan font and emoji directories: %v", err)
+		} else if assets != lastAssets {
+			lastAssets = assets
+			s.reloadFontsAndEmoji()
+			log.Printf("Reloading fonts from %s and emoji from %s", s.Config.FontDir, s.Config.EmojiDir)
+		}
+
+		fingerprint, err := fingerprintDir(s.Config.TemplateDir)
+		if err != nil {
+			log.Printf("Warning: failed to scan template directory: %v", err)
+			continue
+		}
+		if fingerprint == lastFingerprint {
+			continue
+		}
+		lastFingerprint = fingerprint
+
+		if err := s.ReloadTemplates(); err != nil {
+			log.Printf("Warning: template reload failed, keeping last good catalog: %v", err)
+			continue
+		}
+		log.Printf("Reloaded %d templates from %s", len(s.TemplateCatalog()), s.Config.TemplateDir)
+	}
+}
+
+// fingerprintFontsAndEmoji fingerprints FONT_DIR and EMOJI_DIR together
+func (s *MemeService) fingerprintFontsAndEmoji() (string, error) {
+	fonts, err := fingerprintDir(s.Config.FontDir)
+	if err != nil {
+		return "", err
+	}
+	emoji, err := fingerprintDir(s.Config.EmojiDir)
+	if err != nil {
+		return "", err
+	}
+	return fonts + emoji, nil
+}
+
+// fingerprintDir summarizes the names, sizes and modification times of the
+// files in dir so that any change to them produces a different value.
+// A missing directory has an empty fingerprint, so creating it triggers a reload.
+// Hidden entries, such as archived versions and uploads in progress, are ignored.
+func fingerprintDir(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Entries are returned sorted by name, so equal directories hash equally
+	hash := sha256.New()
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// The file disappeared between listing and stat; the next poll will see it
+			continue
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// Shadows and glows are drawn from the silhouette of the text, its coverage mask grown by
+// the outline, so they follow the outline rather than the glyphs inside it. The
+// silhouette is blurred with a Gaussian whose standard deviation is half the blur
+// radius, the convention CSS uses for text-shadow.
+
+// blurReach returns how many pixels a blur of the given radius spreads coverage by
+func blurReach(radius float64) int {
+	return int(math.Ceil(3 * radius / 2))
+}
+
+// effectMargin returns how far the shadow and glow of a style can reach beyond the
+// silhouette of the text, in pixels
+func (r renderStyle) effectMargin() int {
+	margin := 0
+	if r.shadow != nil {
+		margin = max(margin, max(abs(r.shadow.offset.X), abs(r.shadow.offset.Y))+blurReach(r.shadow.blur))
+	}
+	if r.glow != nil {
+		margin = max(margin, r.glow.spread+blurReach(r.glow.blur))
+	}
+	return margin
+}
+
+// drawEffect draws a shadow or glow of the text silhouette, which covers area of dst
+func drawEffect(dst draw.Image, area image.Rectangle, silhouette *image.Alpha, effect *textEffect) {
+	mask := silhouette
+	if effect.spread > 0 {
+		mask = dilate(mask, effect.spread)
+	}
+	mask = gaussianBlur(mask, effect.blur)
+
+	draw.DrawMask(dst, area.Add(effect.offset), image.NewUniform(effect.color), image.Point{}, mask, area.Min, draw.Over)
+}
+
+// gaussianBlur blurs mask with a Gaussian of standard deviation radius/2, as two
+// one-dimensional passes. Coverage beyond the edges of mask counts as empty.
+func gaussianBlur(mask *image.Alpha, radius float64) *image.Alpha {
+	reach := blurReach(radius)
+	if reach == 0 {
+		return mask
+	}
+
+	sigma := radius / 2
+	kernel := make([]float64, 2*reach+1)
+	sum := 0.0
+	for i := range kernel {
+		d := float64(i - reach)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	bounds := mask.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Rows first, into a buffer that keeps the precision for the columns
+	rows := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		src := mask.Pix[y*mask.Stride : y*mask.Stride+width]
+		for x := 0; x < width; x++ {
+			value := 0.0
+			for i, weight := range kernel {
+				if sx := x + i - reach; sx >= 0 && sx < width {
+					value += weight * float64(src[sx])
+				}
+			}
+			rows[y*width+x] = value
+		}
+	}
+
+	blurred := image.NewAlpha(bounds)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := 0.0
+			for i, weight := range kernel {
+				if sy := y + i - reach; sy >= 0 && sy < height {
+					value += weight * rows[sy*width+x]
+				}
+			}
+			blurred.Pix[y*blurred.Stride+x] = uint8(math.Min(value+0.5, 0xff))
+		}
+	}
+
+	return blurred
+}
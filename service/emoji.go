@@ -0,0 +1,309 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/RoMalms10/meme-generator/assets"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// EmojiExtension is the extension of the emoji sprites loaded from EMOJI_DIR
+const EmojiExtension = ".png"
+
+// emojiRuneBase is the first of the private use runes emoji are replaced with for layout,
+// one per distinct emoji in a caption. No font we draw with has glyphs in this plane.
+const emojiRuneBase = 0xF0000
+
+// isEmojiRune reports whether r is one of the runes emoji are replaced with
+func isEmojiRune(r rune) bool {
+	return r >= emojiRuneBase && r < emojiRuneBase+0x10000
+}
+
+const (
+	zeroWidthJoiner     = '\u200d'
+	variationSelector16 = '\ufe0f' // Asks for the emoji presentation of the previous rune
+)
+
+// emojiSet indexes the sprites in EMOJI_DIR, or the built-in sprites when it does not
+// exist. Sprites are named by their code point
+// sequence in hex, joined by dashes, as in Twemoji: 1f600.png, 1f44d-1f3fd.png or
+// 1f468-200d-1f469-200d-1f467.png. Variation selectors are optional in file names and
+// ignored when matching.
+type emojiSet struct {
+	dir       fs.FS             // Where the sprite files are read from
+	files     map[string]string // Sprite file by code point sequence
+	maxLength int               // Longest sequence, in code points
+
+	mu      sync.Mutex
+	sprites map[string]image.Image // Decoded on first use
+}
+
+// loadEmojiSet indexes the sprites in dir, falling back to the built-in sprites when dir
+// does not exist. Files not named by a code point sequence are skipped, and an empty dir
+// gives an empty set.
+func loadEmojiSet(dir string) *emojiSet {
+	if dir == "" {
+		return indexEmojiSet(nil, nil)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Emoji directory %s not found, using the built-in %s sprites", dir, assets.EmojiName)
+		builtin := assets.Emoji()
+		entries, err = fs.ReadDir(builtin, ".")
+		if err != nil {
+			// The sprites are embedded at build time, so this cannot happen
+			panic(err)
+		}
+		return indexEmojiSet(builtin, entries)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to read emoji directory %s: %v", dir, err)
+		return indexEmojiSet(nil, nil)
+	}
+
+	set := indexEmojiSet(os.DirFS(dir), entries)
+	if len(set.files) > 0 {
+		log.Printf("Loaded %d emoji from %s", len(set.files), dir)
+	}
+	return set
+}
+
+// indexEmojiSet indexes the sprites among the entries of dir
+func indexEmojiSet(dir fs.FS, entries []fs.DirEntry) *emojiSet {
+	set := &emojiSet{dir: dir, files: make(map[string]string), sprites: make(map[string]image.Image)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(name), EmojiExtension) {
+			continue
+		}
+		sequence, ok := parseEmojiSequence(strings.TrimSuffix(name, filepath.Ext(name)))
+		if !ok {
+			continue
+		}
+		set.files[string(sequence)] = name
+		set.maxLength = max(set.maxLength, len(sequence))
+	}
+
+	return set
+}
+
+// parseEmojiSequence parses a sprite name into its code points, leaving out variation selectors
+func parseEmojiSequence(name string) ([]rune, bool) {
+	var sequence []rune
+	for _, part := range strings.Split(name, "-") {
+		code, err := strconv.ParseUint(part, 16, 32)
+		if err != nil {
+			return nil, false
+		}
+		if r := rune(code); r != variationSelector16 {
+			sequence = append(sequence, r)
+		}
+	}
+	return sequence, len(sequence) > 0
+}
+
+// match returns the longest sequence with a sprite at the start of runes and how many
+// runes it spans, including variation selectors within or right after it
+func (e *emojiSet) match(runes []rune) (key string, n int) {
+	var sequence []rune
+	for i, r := range runes {
+		if r == variationSelector16 {
+			if i == n && n > 0 {
+				n++
+			}
+			continue
+		}
+		if len(sequence) == e.maxLength {
+			break
+		}
+		sequence = append(sequence, r)
+		if _, exists := e.files[string(sequence)]; exists {
+			key, n = string(sequence), i+1
+		}
+	}
+	return key, n
+}
+
+// sprite returns the decoded sprite of a sequence
+func (e *emojiSet) sprite(key string) (image.Image, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if sprite, exists := e.sprites[key]; exists {
+		return sprite, nil
+	}
+
+	file, err := e.dir.Open(e.files[key])
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sprite, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", e.files[key], err)
+	}
+	if sprite.Bounds().Empty() {
+		return nil, fmt.Errorf("%s is empty", e.files[key])
+	}
+	e.sprites[key] = sprite
+	return sprite, nil
+}
+
+// substitute replaces every emoji in text that has a sprite with a private use rune and
+// returns the sprites those runes stand for. Joiners and variation selectors left over
+// from sequences without a sprite of their own are dropped, as they are invisible.
+func (e *emojiSet) substitute(text string) (string, []image.Image) {
+	if len(e.files) == 0 {
+		return text, nil
+	}
+
+	runes := []rune(text)
+	var b strings.Builder
+	var sprites []image.Image
+	placeholders := make(map[string]rune)
+	afterEmoji := false
+
+	for i := 0; i < len(runes); {
+		if key, n := e.match(runes[i:]); n > 0 {
+			placeholder, seen := placeholders[key]
+			if !seen {
+				sprite, err := e.sprite(key)
+				if err != nil {
+					log.Printf("Warning: skipping emoji sprite: %v", err)
+				} else {
+					placeholder = emojiRuneBase + rune(len(sprites))
+					placeholders[key] = placeholder
+					sprites = append(sprites, sprite)
+					seen = true
+				}
+			}
+			if seen {
+				b.WriteRune(placeholder)
+				i += n
+				afterEmoji = true
+				continue
+			}
+		}
+
+		r := runes[i]
+		i++
+		if r == variationSelector16 || (r == zeroWidthJoiner && afterEmoji) {
+			continue
+		}
+		b.WriteRune(r)
+		afterEmoji = false
+	}
+
+	return b.String(), sprites
+}
+
+// emojiSet returns the sprites in EMOJI_DIR, or the built-in ones, indexing them on first use
+func (s *MemeService) emojiSet() *emojiSet {
+	s.fontsMu.Lock()
+	defer s.fontsMu.Unlock()
+
+	if s.emoji == nil {
+		s.emoji = loadEmojiSet(s.Config.EmojiDir)
+	}
+	return s.emoji
+}
+
+// emojiFace is a face for text whose emoji were replaced by substitute. Each emoji is as
+// tall as the font size, centred on the text, and as wide as its sprite's aspect ratio
+// makes it. It has no glyph mask, so the text outline leaves emoji alone; drawSprites
+// draws them in colour.
+type emojiFace struct {
+	font.Face
+	sprites []image.Image
+	size    float64
+}
+
+// sprite returns the sprite r stands for, if it is an emoji rune
+func (f *emojiFace) sprite(r rune) (image.Image, bool) {
+	i := int(r - emojiRuneBase)
+	if i < 0 || i >= len(f.sprites) {
+		return nil, false
+	}
+	return f.sprites[i], true
+}
+
+// spriteBounds returns where a sprite is drawn relative to the dot
+func (f *emojiFace) spriteBounds(sprite image.Image) fixed.Rectangle26_6 {
+	metrics := f.Face.Metrics()
+	height := fixed.Int26_6(f.size * 64)
+	width := height * fixed.Int26_6(sprite.Bounds().Dx()) / fixed.Int26_6(sprite.Bounds().Dy())
+	top := -(metrics.Ascent - metrics.Descent + height) / 2
+
+	return fixed.Rectangle26_6{Min: fixed.Point26_6{Y: top}, Max: fixed.Point26_6{X: width, Y: top + height}}
+}
+
+// Glyph returns no mask for emoji, which drawSprites draws instead
+func (f *emojiFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	if sprite, ok := f.sprite(r); ok {
+		return image.Rectangle{}, nil, image.Point{}, f.spriteBounds(sprite).Max.X, true
+	}
+	return f.Face.Glyph(dot, r)
+}
+
+// GlyphBounds returns the bounds of r, the sprite's for emoji
+func (f *emojiFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	if sprite, ok := f.sprite(r); ok {
+		bounds := f.spriteBounds(sprite)
+		return bounds, bounds.Max.X, true
+	}
+	return f.Face.GlyphBounds(r)
+}
+
+// GlyphAdvance returns the advance of r, the sprite's width for emoji
+func (f *emojiFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	if sprite, ok := f.sprite(r); ok {
+		return f.spriteBounds(sprite).Max.X, true
+	}
+	return f.Face.GlyphAdvance(r)
+}
+
+// Kern returns the kerning between two runes; emoji are not kerned
+func (f *emojiFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	_, emoji0 := f.sprite(r0)
+	_, emoji1 := f.sprite(r1)
+	if emoji0 || emoji1 {
+		return 0
+	}
+	return f.Face.Kern(r0, r1)
+}
+
+// drawSprites draws the emoji of a layout with its top-left corner at origin, walking
+// each line the way font.Drawer does so they land where layout left room for them
+func (f *emojiFace) drawSprites(dst draw.Image, layout *TextLayout, origin image.Point) {
+	for _, line := range layout.Lines {
+		dot := fixed.P(origin.X+line.Bounds.Min.X, origin.Y+line.Baseline)
+		prev := rune(-1)
+		for _, r := range line.Text {
+			if prev >= 0 {
+				dot.X += f.Kern(prev, r)
+			}
+			if sprite, ok := f.sprite(r); ok {
+				bounds := f.spriteBounds(sprite).Add(dot)
+				rect := image.Rect(bounds.Min.X.Round(), bounds.Min.Y.Round(), bounds.Max.X.Round(), bounds.Max.Y.Round())
+				draw.CatmullRom.Scale(dst, rect, sprite, sprite.Bounds(), draw.Over, nil)
+			}
+			advance, _ := f.GlyphAdvance(r)
+			dot.X += advance
+			prev = r
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/image/font"
+)
+
+// Overflow policies for captions that do not fit their box even at the minimum font size.
+// The configuration only accepts these values for TEXT_OVERFLOW.
+const (
+	OverflowEllipsis = "ellipsis" // Drop the lines that do not fit and end the last one with an ellipsis
+	OverflowVisible  = "overflow" // Draw every line, even past the edge of the box
+	OverflowReject   = "reject"   // Fail the request
+)
+
+// Font size limits used when the configuration leaves them unset
+const (
+	DefaultMinFontSize = 12
+	DefaultMaxFontSize = 48
+)
+
+// ErrCaptionTooLong is returned when a caption does not fit and the overflow policy rejects it
+var ErrCaptionTooLong = errors.New("caption does not fit")
+
+// captionBox is the space a caption has to fit in, in pixels
+type captionBox struct {
+	name     string // Names the caption in errors
+	width    int    // Includes the padding kept clear for the outline
+	height   int
+	maxLines int     // 0 allows any number of lines
+	size     float64 // Font size to start from, unless the caption has a fixed size
+}
+
+// fittedCaption is a caption laid out at the size it is drawn at
+type fittedCaption struct {
+	face    font.Face
+	layout  *TextLayout
+	size    float64
+	padding int // Horizontal offset of the layout within the box
+}
+
+// fontSizeLimits returns the configured range captions are sized within
+func (s *MemeService) fontSizeLimits() (float64, float64) {
+	minSize, maxSize := s.Config.MinFontSize, s.Config.MaxFontSize
+	if minSize <= 0 {
+		minSize = DefaultMinFontSize
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxFontSize
+	}
+	return minSize, max(minSize, maxSize)
+}
+
+// fitCaption lays out a caption in its box. With auto-fit enabled the font shrinks one
+// point at a time from box.size down to the minimum size until the wrapped caption fits;
+// otherwise, or when the caption has a fixed size, the size does not change. A caption
+// that still does not fit is handled by its overflow policy.
+func (s *MemeService) fitCaption(caption styledCaption, box captionBox) (*fittedCaption, error) {
+	size, minSize := box.size, box.size
+	if caption.size > 0 {
+		size, minSize = caption.size, caption.size
+	} else if s.Config.AutoFitText {
+		limit, _ := s.fontSizeLimits()
+		minSize = min(minSize, limit)
+	}
+
+	fit := s.layoutCaption(caption, box, size, LayoutOptions{MaxLines: box.maxLines})
+	for !fit.fits(box) && size > minSize {
+		size = max(size-1, minSize)
+		fit = s.layoutCaption(caption, box, size, LayoutOptions{MaxLines: box.maxLines})
+	}
+	if fit.fits(box) {
+		return fit, nil
+	}
+
+	overflow := caption.overflow
+	if overflow == "" {
+		overflow = s.Config.TextOverflow
+	}
+	switch overflow {
+	case OverflowVisible:
+		return fit, nil
+	case OverflowReject:
+		return nil, fmt.Errorf("%w: %s is too long for its %dx%d box even at %gpt", ErrCaptionTooLong, box.name, box.width, box.height, size)
+	}
+
+	// Keep as many lines as the box holds and mark the cut with an ellipsis
+	maxLines := max(box.height/max(int(size*s.Config.LineSpacing), 1), 1)
+	if box.maxLines > 0 {
+		maxLines = min(maxLines, box.maxLines)
+	}
+	ellipsis := "…"
+	if !caption.fonts.hasGlyph('…') {
+		ellipsis = "..."
+	}
+	return s.layoutCaption(caption, box, size, LayoutOptions{MaxLines: maxLines, Ellipsis: ellipsis}), nil
+}
+
+// layoutCaption lays out a caption at the given font size, filling in the width, line
+// height and alignment of opts
+func (s *MemeService) layoutCaption(caption styledCaption, box captionBox, size float64, opts LayoutOptions) *fittedCaption {
+	face := caption.face(size)
+	padding := captionPadding(size, caption.style)
+
+	opts.MaxWidth = box.width - 2*padding
+	opts.LineHeight = int(size * s.Config.LineSpacing)
+	opts.Align = caption.style.align
+
+	return &fittedCaption{
+		face:    face,
+		layout:  LayoutText(face, caption.style.transform(caption.text), opts),
+		size:    size,
+		padding: padding,
+	}
+}
+
+// fits reports whether every line of the caption was kept and fits inside the box
+func (c *fittedCaption) fits(box captionBox) bool {
+	return !c.layout.Truncated && c.layout.Height <= box.height && c.layout.Width <= box.width-2*c.padding
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"image"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// FontExtension is the extension of the font files loaded from FONT_DIR
+const FontExtension = ".ttf"
+
+// fontRegistry holds the fonts found in FONT_DIR, keyed by lower-cased name
+type fontRegistry struct {
+	fonts map[string]*truetype.Font
+}
+
+// FontName returns the name a font file is registered under: its file name without the extension
+func FontName(filename string) string {
+	return strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+}
+
+// loadFontRegistry parses every font file in dir. Files that fail to parse are skipped
+// with a warning, and a missing directory gives an empty registry.
+func loadFontRegistry(dir string) *fontRegistry {
+	registry := &fontRegistry{fonts: make(map[string]*truetype.Font)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Warning: failed to read font directory %s: %v", dir, err)
+		}
+		return registry
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), FontExtension) {
+			continue
+		}
+
+		fontData, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err == nil {
+			var f *truetype.Font
+			if f, err = freetype.ParseFont(fontData); err == nil {
+				registry.fonts[strings.ToLower(FontName(entry.Name()))] = f
+				continue
+			}
+		}
+		log.Printf("Warning: skipping font %s: %v", entry.Name(), err)
+	}
+
+	return registry
+}
+
+// lookup returns the font registered under name. The name may carry the font file
+// extension, which is how text styles named fonts before the registry existed.
+func (r *fontRegistry) lookup(name string) (*truetype.Font, bool) {
+	if strings.EqualFold(filepath.Ext(name), FontExtension) {
+		name = FontName(name)
+	}
+	f, exists := r.fonts[strings.ToLower(name)]
+	return f, exists
+}
+
+// names returns the registered font names, sorted
+func (r *fontRegistry) names() []string {
+	names := make([]string, 0, len(r.fonts))
+	for name := range r.fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fontDir returns the directory fonts are loaded from, the directory of FONT_FILE
+// unless FONT_DIR is set
+func (s *MemeService) fontDir() string {
+	if s.Config.FontDir != "" {
+		return s.Config.FontDir
+	}
+	return filepath.Dir(s.Config.FontFile)
+}
+
+// fontRegistry returns the fonts in FONT_DIR, loading them on first use
+func (s *MemeService) fontRegistry() *fontRegistry {
+	s.fontsMu.Lock()
+	defer s.fontsMu.Unlock()
+
+	if s.fonts == nil {
+		dir := s.fontDir()
+		s.fonts = loadFontRegistry(dir)
+		log.Printf("Loaded %d fonts from %s: %s", len(s.fonts.fonts), dir, strings.Join(s.fonts.names(), ", "))
+		for _, name := range s.Config.FontFallbacks {
+			if _, exists := s.fonts.lookup(name); !exists {
+				log.Printf("Warning: fallback font %s not found in %s", name, dir)
+			}
+		}
+	}
+	return s.fonts
+}
+
+// Fonts lists the names of the fonts captions and text styles can select
+func (s *MemeService) Fonts() []string {
+	return s.fontRegistry().names()
+}
+
+// loadNamedFont returns a font from the registry
+func (s *MemeService) loadNamedFont(name string) (*truetype.Font, error) {
+	f, exists := s.fontRegistry().lookup(name)
+	if !exists {
+		return nil, fs.ErrNotExist
+	}
+	return f, nil
+}
+
+// fontChain is a font followed by the fallbacks tried, in order, for runes it has no glyph for
+type fontChain []*truetype.Font
+
+// withFallbacks returns primary followed by the configured fallback fonts. The built-in
+// font only covers Latin scripts, so it is followed by the built-in fallback font last.
+func (s *MemeService) withFallbacks(primary *truetype.Font) fontChain {
+	chain := fontChain{primary}
+	registry := s.fontRegistry()
+	for _, name := range s.Config.FontFallbacks {
+		if f, exists := registry.lookup(name); exists && f != primary {
+			chain = append(chain, f)
+		}
+	}
+	if fallback, builtin := builtinFallbackFor(primary); builtin {
+		chain = append(chain, fallback)
+	}
+	return chain
+}
+
+// pick returns the position of the first font in the chain with a glyph for r, or 0,
+// the primary font, when none has one
+func (c fontChain) pick(r rune) int {
+	for i, f := range c {
+		if f.Index(r) != 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// hasGlyph reports whether any font in the chain has a glyph for r
+func (c fontChain) hasGlyph(r rune) bool {
+	return c[c.pick(r)].Index(r) != 0
+}
+
+// fallbackFace is a face over a font chain. Every rune is measured and drawn with the
+// first font that has a glyph for it, so layout and drawing agree on mixed-font text.
+type fallbackFace struct {
+	fonts fontChain
+	size  float64
+	faces []font.Face  // Created on first use
+	picks map[rune]int // Font chosen for each rune seen so far
+}
+
+// face returns the face of the font chosen for r and its position in the chain
+func (f *fallbackFace) face(r rune) (font.Face, int) {
+	i, seen := f.picks[r]
+	if !seen {
+		i = f.fonts.pick(r)
+		f.picks[r] = i
+	}
+	return f.faceAt(i), i
+}
+
+// faceAt returns the face of font i in the chain
+func (f *fallbackFace) faceAt(i int) font.Face {
+	if f.faces[i] == nil {
+		f.faces[i] = newTrueTypeFace(f.fonts[i], f.size)
+	}
+	return f.faces[i]
+}
+
+// Close releases the faces that were created
+func (f *fallbackFace) Close() error {
+	for _, face := range f.faces {
+		if face != nil {
+			face.Close()
+		}
+	}
+	return nil
+}
+
+// Glyph returns the glyph of r from its font
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	face, _ := f.face(r)
+	return face.Glyph(dot, r)
+}
+
+// GlyphBounds returns the bounds of r in its font
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	face, _ := f.face(r)
+	return face.GlyphBounds(r)
+}
+
+// GlyphAdvance returns the advance of r in its font
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	face, _ := f.face(r)
+	return face.GlyphAdvance(r)
+}
+
+// Kern returns the kerning between two runes drawn with the same font; runes from
+// different fonts are not kerned
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face0, i0 := f.face(r0)
+	if _, i1 := f.face(r1); i0 != i1 {
+		return 0
+	}
+	return face0.Kern(r0, r1)
+}
+
+// Metrics returns the metrics of the primary font, which set the line spacing
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faceAt(0).Metrics()
+}
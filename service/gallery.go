@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GalleryThumbnailDir is the directory inside the gallery that holds the rendered thumbnails
+const GalleryThumbnailDir = "thumbnails"
+
+// GalleryReport summarizes a gallery run
+type GalleryReport struct {
+	Index     string   // Path of the generated HTML page
+	Templates int      // Number of templates in the gallery
+	Failed    []string // Templates that could not be rendered, with the reason
+}
+
+// galleryEntry is one template as shown in the gallery
+type galleryEntry struct {
+	*TemplateInfo
+	Thumbnail string
+	Captions  []string
+}
+
+// galleryPage is the data the gallery template is executed with
+type galleryPage struct {
+	Entries     []galleryEntry
+	GeneratedAt time.Time
+}
+
+// WriteGallery renders every template in the catalog with its example captions, writes
+// the thumbnails to outDir and builds a static HTML page listing them. Templates that
+// fail to render are left out and reported; the rest of the gallery is still written.
+func (s *MemeService) WriteGallery(outDir, size string) (*GalleryReport, error) {
+	if err := os.MkdirAll(filepath.Join(outDir, GalleryThumbnailDir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create gallery directory: %v", err)
+	}
+
+	catalog := s.TemplateCatalog()
+	ids := make([]string, 0, len(catalog))
+	for id := range catalog {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	report := &GalleryReport{Index: filepath.Join(outDir, "index.html")}
+	page := galleryPage{GeneratedAt: time.Now().UTC()}
+	for _, id := range ids {
+		info := *catalog[id]
+		info.ID = id
+
+		data, err := s.TemplatePreview(info.Filename, size, true)
+		if err != nil {
+			report.Failed = append(report.Failed, fmt.Sprintf("%s: %v", id, err))
+			continue
+		}
+
+		thumbnail := filepath.Join(GalleryThumbnailDir, id+".jpg")
+		if err := os.WriteFile(filepath.Join(outDir, thumbnail), data, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write thumbnail: %v", err)
+		}
+
+		top, bottom, additional := placeholderCaptions(&info)
+		captions := append([]string{top, bottom}, additional...)[:info.TextFieldCount]
+
+		page.Entries = append(page.Entries, galleryEntry{
+			TemplateInfo: &info,
+			Thumbnail:    filepath.ToSlash(thumbnail),
+			Captions:     captions,
+		})
+	}
+
+	sort.SliceStable(page.Entries, func(i, j int) bool {
+		return templateOrders[SortByName](page.Entries[i].TemplateInfo, page.Entries[j].TemplateInfo)
+	})
+
+	var html strings.Builder
+	if err := galleryTemplate.Execute(&html, page); err != nil {
+		return nil, fmt.Errorf("failed to render gallery: %v", err)
+	}
+	if err := writeFileAtomic(report.Index, []byte(html.String())); err != nil {
+		return nil, fmt.Errorf("failed to write gallery: %v", err)
+	}
+
+	report.Templates = len(page.Entries)
+	return report, nil
+}
+
+// galleryTemplate is the gallery page. It has no external dependencies, so the output
+// directory can be opened from disk or served as is.
+var galleryTemplate = template.Must(template.New("gallery").Funcs(template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Meme template gallery</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0; padding: 24px; background: #f4f4f5; color: #18181b; }
+header { display: flex; flex-wrap: wrap; align-items: baseline; gap: 16px; margin-bottom: 24px; }
+h1 { margin: 0; font-size: 24px; }
+header p { margin: 0; color: #71717a; }
+input { padding: 8px 12px; font-size: 16px; border: 1px solid #d4d4d8; border-radius: 6px; min-width: 260px; }
+main { display: grid; grid-template-columns: repeat(auto-fill, minmax(280px, 1fr)); gap: 16px; }
+article { background: #fff; border-radius: 8px; overflow: hidden; box-shadow: 0 1px 3px rgba(0, 0, 0, 0.1); }
+article img { display: block; width: 100%; height: 220px; object-fit: contain; background: #27272a; }
+article div { padding: 12px 16px 16px; }
+h2 { margin: 0 0 4px; font-size: 18px; }
+code { background: #f4f4f5; padding: 1px 4px; border-radius: 4px; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: 4px 12px; margin: 12px 0 0; font-size: 14px; }
+dt { color: #71717a; }
+dd { margin: 0; }
+ol { margin: 0; padding-left: 20px; }
+</style>
+</head>
+<body>
+<header>
+<h1>Meme template gallery</h1>
+<p>{{len .Entries}} templates, generated {{.GeneratedAt.Format "2006-01-02 15:04 UTC"}}</p>
+<input type="search" id="filter" placeholder="Filter by name, id, category or tag" aria-label="Filter templates">
+</header>
+<main>
+{{- range .Entries}}
+<article data-search="{{lower .Name}} {{.ID}} {{join .Aliases " "}} {{lower (join .AllCategories " ")}} {{lower (join .Tags " ")}}">
+<img src="{{.Thumbnail}}" alt="{{.Name}}" loading="lazy">
+<div>
+<h2>{{.Name}}</h2>
+<code>{{.ID}}</code>
+<dl>
+<dt>Categories</dt><dd>{{join .AllCategories ", "}}</dd>
+{{- if .Tags}}
+<dt>Tags</dt><dd>{{join .Tags ", "}}</dd>
+{{- end}}
+{{- if .Aliases}}
+<dt>Aliases</dt><dd>{{join .Aliases ", "}}</dd>
+{{- end}}
+<dt>Text fields</dt><dd>{{.TextFieldCount}}</dd>
+<dt>Example captions</dt><dd><ol>{{range .Captions}}<li>{{.}}</li>{{end}}</ol></dd>
+</dl>
+</div>
+</article>
+{{- end}}
+</main>
+<script>
+document.getElementById("filter").addEventListener("input", function (event) {
+  var query = event.target.value.toLowerCase();
+  document.querySelectorAll("article").forEach(function (card) {
+    card.hidden = query !== "" && card.dataset.search.indexOf(query) === -1;
+  });
+});
+</script>
+</body>
+</html>
+`))
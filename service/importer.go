@@ -0,0 +1,178 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Outcomes of importing a single catalog entry
+const (
+	ImportImported = "imported"
+	ImportConflict = "conflict" // The id is already taken; the existing template is kept
+	ImportFailed   = "failed"
+)
+
+// ImgflipTemplate is one entry of an Imgflip-style template catalog
+type ImgflipTemplate struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	BoxCount int    `json:"box_count"`
+}
+
+// ImportResult reports what happened to one catalog entry
+type ImportResult struct {
+	SourceID   string `json:"source_id"`
+	TemplateID string `json:"template_id"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+}
+
+// ParseImgflipCatalog reads an Imgflip-style catalog. It accepts both the get_memes
+// response, {"data": {"memes": [...]}}, and a bare array of entries.
+func ParseImgflipCatalog(r io.Reader) ([]ImgflipTemplate, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %v", err)
+	}
+
+	var entries []ImgflipTemplate
+	if err := json.Unmarshal(data, &entries); err == nil {
+		return entries, nil
+	}
+
+	var response struct {
+		Data struct {
+			Memes []ImgflipTemplate `json:"memes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %v", err)
+	}
+
+	return response.Data.Memes, nil
+}
+
+// Slugify turns a template name into an id: "Y'all Got Any More" becomes
+// "yall-got-any-more" and accents are dropped, so "Pokémon" becomes "pokemon"
+func Slugify(name string) string {
+	var slug strings.Builder
+	pendingDash := false
+
+	// Decompose accented letters so the marks can be dropped
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if pendingDash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			pendingDash = false
+			slug.WriteRune(unicode.ToLower(r))
+		default:
+			pendingDash = true
+		}
+	}
+
+	return slug.String()
+}
+
+// ImportImgflipTemplates creates a template for each catalog entry, reading the images
+// from imageDir. Ids already in the catalog are reported as conflicts and left untouched.
+func (s *MemeService) ImportImgflipTemplates(entries []ImgflipTemplate, imageDir, category string) []ImportResult {
+	results := make([]ImportResult, 0, len(entries))
+
+	for _, entry := range entries {
+		result := ImportResult{SourceID: entry.ID, TemplateID: Slugify(entry.Name)}
+		if result.TemplateID == "" {
+			// Names without any Latin letters or digits fall back to the source id
+			result.TemplateID = Slugify("imgflip " + entry.ID)
+		}
+
+		err := s.importImgflipTemplate(entry, result.TemplateID, imageDir, category)
+		switch {
+		case err == nil:
+			result.Status = ImportImported
+		case errors.Is(err, ErrTemplateExists):
+			result.Status = ImportConflict
+			result.Message = err.Error()
+		default:
+			result.Status = ImportFailed
+			result.Message = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// importImgflipTemplate stores a single catalog entry as a template with the given id
+func (s *MemeService) importImgflipTemplate(entry ImgflipTemplate, id, imageDir, category string) error {
+	if _, exists := s.Template(id); exists {
+		return fmt.Errorf("%w: '%s'", ErrTemplateExists, id)
+	}
+
+	imagePath, err := findImportImage(entry, id, imageDir)
+	if err != nil {
+		return err
+	}
+
+	img, err := os.Open(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to open image: %v", err)
+	}
+	defer img.Close()
+
+	info := &TemplateInfo{
+		ID:             id,
+		Name:           entry.Name,
+		TextFieldCount: int32(entry.BoxCount),
+		Category:       category,
+	}
+
+	_, err = s.CreateTemplate(info, img)
+	return err
+}
+
+// findImportImage locates the image of a catalog entry in imageDir. It tries the file
+// name from the entry's URL, then the source id and the new template id with common extensions.
+func findImportImage(entry ImgflipTemplate, id, imageDir string) (string, error) {
+	var candidates []string
+	if parsed, err := url.Parse(entry.URL); err == nil && parsed.Path != "" {
+		candidates = append(candidates, path.Base(parsed.Path))
+	}
+	for _, base := range []string{entry.ID, id} {
+		if base == "" {
+			continue
+		}
+		for _, ext := range []string{".jpg", ".jpeg", ".png"} {
+			candidates = append(candidates, base+ext)
+		}
+	}
+
+	for _, candidate := range candidates {
+		// Only plain file names are accepted so the URL cannot point outside imageDir
+		if candidate != filepath.Base(candidate) || candidate == "." || candidate == ".." {
+			continue
+		}
+		imagePath := filepath.Join(imageDir, candidate)
+		if stat, err := os.Stat(imagePath); err == nil && stat.Mode().IsRegular() {
+			return imagePath, nil
+		}
+	}
+
+	return "", fmt.Errorf("no image found in %s (tried %s)", imageDir, strings.Join(candidates, ", "))
+}
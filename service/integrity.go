@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Health statuses reported by Health
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded" // Serving, but some templates are quarantined
+)
+
+// QuarantinedTemplate is a template left out of the catalog because its image failed verification
+type QuarantinedTemplate struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Reason   string `json:"reason"`
+}
+
+// HealthReport summarizes the state of the template catalog
+type HealthReport struct {
+	Status      string                `json:"status"`
+	Templates   int                   `json:"templates"`
+	Quarantined []QuarantinedTemplate `json:"quarantined"`
+	Unverified  []string              `json:"unverified,omitempty"` // Templates without a checksum in the manifest
+}
+
+// Quarantined reports whether the template failed verification when it was loaded
+func (t *TemplateInfo) Quarantined() bool {
+	return t.quarantine != ""
+}
+
+// verifyChecksum compares the checksum of a template image with the one declared in
+// the manifest and quarantines the template on a mismatch. Templates without a declared
+// checksum adopt the computed one and are marked unverified, since an image that was
+// already damaged when it was added would pass.
+func verifyChecksum(info *TemplateInfo, checksum string) {
+	if checksum == "" {
+		return
+	}
+
+	declared := strings.ToLower(info.SHA256)
+	switch declared {
+	case "":
+		info.SHA256 = checksum
+		info.unverified = true
+	case checksum:
+		info.SHA256 = declared
+	default:
+		info.quarantine = fmt.Sprintf("image %s has sha256 %s, manifest records %s", info.Filename, checksum, declared)
+	}
+}
+
+// publishCatalog archives and swaps in the healthy templates and keeps the quarantined ones aside
+func (s *MemeService) publishCatalog(templates map[string]*TemplateInfo) {
+	catalog, quarantined := splitQuarantined(templates)
+	s.archiveTemplateVersions(catalog)
+	s.installCatalog(catalog, quarantined)
+}
+
+// splitQuarantined separates the templates that failed verification from the healthy ones
+func splitQuarantined(templates map[string]*TemplateInfo) (catalog, quarantined map[string]*TemplateInfo) {
+	catalog = make(map[string]*TemplateInfo, len(templates))
+	quarantined = make(map[string]*TemplateInfo)
+	for id, info := range templates {
+		if info.Quarantined() {
+			quarantined[id] = info
+			log.Printf("Warning: template %s quarantined: %s", id, info.quarantine)
+			continue
+		}
+		if info.unverified {
+			log.Printf("Warning: template %s has no sha256 in the manifest, so image %s is trusted as it is (sha256 %s)", id, info.Filename, info.SHA256)
+		}
+		catalog[id] = info
+	}
+	return catalog, quarantined
+}
+
+// installCatalog swaps in the healthy and quarantined templates and drops the cached
+// previews of entries that are no longer in the catalog
+func (s *MemeService) installCatalog(catalog, quarantined map[string]*TemplateInfo) {
+	s.mu.Lock()
+	s.Templates = catalog
+	s.quarantined = quarantined
+	s.mu.Unlock()
+
+	s.prunePreviews(catalog)
+}
+
+// manifestTemplates returns the catalog together with the quarantined templates, which is
+// everything the manifest holds. The returned map is a copy the caller may modify.
+func (s *MemeService) manifestTemplates() map[string]*TemplateInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make(map[string]*TemplateInfo, len(s.Templates)+len(s.quarantined)+1)
+	for id, info := range s.quarantined {
+		templates[id] = info
+	}
+	for id, info := range s.Templates {
+		templates[id] = info
+	}
+	return templates
+}
+
+// storedTemplate returns a template from the catalog or, failing that, from quarantine
+func (s *MemeService) storedTemplate(id string) (*TemplateInfo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if info, exists := s.Templates[id]; exists {
+		return info, true
+	}
+	info, exists := s.quarantined[id]
+	return info, exists
+}
+
+// QuarantinedTemplates lists the templates that failed verification, sorted by id
+func (s *MemeService) QuarantinedTemplates() []QuarantinedTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quarantined := make([]QuarantinedTemplate, 0, len(s.quarantined))
+	for id, info := range s.quarantined {
+		quarantined = append(quarantined, QuarantinedTemplate{ID: id, Filename: info.Filename, Reason: info.quarantine})
+	}
+	sort.Slice(quarantined, func(i, j int) bool {
+		return quarantined[i].ID < quarantined[j].ID
+	})
+
+	return quarantined
+}
+
+// Health reports the size of the catalog, any quarantined templates and the templates
+// whose images could not be verified
+func (s *MemeService) Health() HealthReport {
+	catalog := s.TemplateCatalog()
+	report := HealthReport{
+		Status:      HealthOK,
+		Templates:   len(catalog),
+		Quarantined: s.QuarantinedTemplates(),
+	}
+	if len(report.Quarantined) > 0 {
+		report.Status = HealthDegraded
+	}
+
+	for id, info := range catalog {
+		if info.unverified {
+			report.Unverified = append(report.Unverified, id)
+		}
+	}
+	sort.Strings(report.Unverified)
+
+	return report
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AllCategories returns the primary category followed by any additional categories, without duplicates
+func (t *TemplateInfo) AllCategories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+
+	for _, category := range append([]string{t.Category}, t.Categories...) {
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+		categories = append(categories, category)
+	}
+
+	return categories
+}
+
+// HasCategory reports whether the template belongs to category, ignoring case
+func (t *TemplateInfo) HasCategory(category string) bool {
+	for _, candidate := range t.AllCategories() {
+		if strings.EqualFold(candidate, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag reports whether the template carries tag, ignoring case
+func (t *TemplateInfo) HasTag(tag string) bool {
+	for _, candidate := range t.Tags {
+		if strings.EqualFold(candidate, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLabel reports whether label is one of the template's categories or tags
+func (t *TemplateInfo) HasLabel(label string) bool {
+	return t.HasCategory(label) || t.HasTag(label)
+}
+
+// ResolveTemplate looks up a template by id or by one of its aliases.
+// It returns the template along with its canonical id.
+func (s *MemeService) ResolveTemplate(name string) (*TemplateInfo, string, bool) {
+	catalog := s.TemplateCatalog()
+
+	if info, exists := catalog[name]; exists {
+		return info, name, true
+	}
+
+	for id, info := range catalog {
+		for _, alias := range info.Aliases {
+			if alias == name {
+				return info, id, true
+			}
+		}
+	}
+
+	return nil, "", false
+}
+
+// normalizeTemplate promotes the first additional category to the primary category when none is set
+func normalizeTemplate(info *TemplateInfo) {
+	if info.Category == "" && len(info.Categories) > 0 {
+		info.Category = info.Categories[0]
+	}
+}
+
+// validateLabels checks the categories, tags and aliases of a template
+func validateLabels(info *TemplateInfo) []string {
+	var problems []string
+
+	for _, category := range info.Categories {
+		if strings.TrimSpace(category) == "" {
+			problems = append(problems, "categories must not contain empty values")
+			break
+		}
+	}
+
+	for _, tag := range info.Tags {
+		if strings.TrimSpace(tag) == "" {
+			problems = append(problems, "tags must not contain empty values")
+			break
+		}
+	}
+
+	for _, alias := range info.Aliases {
+		if !templateIDPattern.MatchString(alias) {
+			problems = append(problems, fmt.Sprintf("alias '%s' must contain only lowercase letters, digits and single dashes", alias))
+		} else if alias == info.ID {
+			problems = append(problems, fmt.Sprintf("alias '%s' repeats the template id", alias))
+		}
+	}
+
+	return problems
+}
+
+// checkAliasConflicts reports aliases that collide with another template's id or alias
+func checkAliasConflicts(templates map[string]*TemplateInfo) []templateProblem {
+	var problems []templateProblem
+	owners := make(map[string]string)
+
+	// Visit templates in id order so the reported conflicts are deterministic
+	ids := make([]string, 0, len(templates))
+	for id := range templates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		for _, alias := range templates[id].Aliases {
+			if _, isID := templates[alias]; isID && alias != id {
+				problems = append(problems, templateProblem{id: id, message: fmt.Sprintf("alias '%s' is the id of another template", alias)})
+				continue
+			}
+			if owner, taken := owners[alias]; taken && owner != id {
+				problems = append(problems, templateProblem{id: id, message: fmt.Sprintf("alias '%s' is already used by template '%s'", alias, owner)})
+				continue
+			}
+			owners[alias] = id
+		}
+	}
+
+	return problems
+}
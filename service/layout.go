@@ -0,0 +1,230 @@
+package service
+
+import (
+	"image"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// LayoutOptions control how LayoutText breaks and places lines
+type LayoutOptions struct {
+	MaxWidth   int    // Width available to every line, in pixels
+	LineHeight int    // Distance between consecutive baselines, in pixels
+	Align      string // AlignLeft, AlignCenter (default), AlignRight, AlignStart or AlignEnd within MaxWidth
+	MaxLines   int    // Lines beyond this count are dropped; 0 keeps every line
+	Ellipsis   string // Ends the last kept line when lines are dropped; empty drops them silently
+}
+
+// LineBox is one laid-out line. Coordinates are relative to the top-left corner of
+// the layout, which is MaxWidth wide.
+type LineBox struct {
+	Text     string          `json:"text"`          // In visual order, as drawn from left to right
+	RTL      bool            `json:"rtl,omitempty"` // The line is part of a right-to-left paragraph
+	Bounds   image.Rectangle `json:"bounds"`        // The advance width of the text by the line height
+	Baseline int             `json:"baseline"`      // y coordinate of the baseline
+}
+
+// TextLayout is text broken into lines and positioned within its box
+type TextLayout struct {
+	Lines     []LineBox `json:"lines"`
+	Width     int       `json:"width"`     // Width of the widest line
+	Height    int       `json:"height"`    // Number of lines times the line height
+	Truncated bool      `json:"truncated"` // Lines were dropped to honor MaxLines
+}
+
+// newFontFace returns the face text is both measured and drawn with, so that
+// line breaks match what ends up on the image
+func newFontFace(fonts fontChain, size float64) font.Face {
+	if len(fonts) == 1 {
+		return newTrueTypeFace(fonts[0], size)
+	}
+	return &fallbackFace{
+		fonts: fonts,
+		size:  size,
+		faces: make([]font.Face, len(fonts)),
+		picks: make(map[rune]int),
+	}
+}
+
+// newTrueTypeFace returns a face for one font
+func newTrueTypeFace(f *truetype.Font, size float64) font.Face {
+	return truetype.NewFace(f, &truetype.Options{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// LayoutText breaks text into lines no wider than opts.MaxWidth, measuring the real
+// advances of face. Lines break at spaces, at explicit newlines and between the
+// characters of scripts written without spaces, such as Chinese and Japanese, keeping
+// the kinsoku rules; a word wider than a whole line is broken between characters. Each
+// line is vertically centered within its line height and aligned horizontally within
+// MaxWidth.
+//
+// Lines are broken in logical order and then put in visual order, so right-to-left and
+// mixed-direction paragraphs wrap the way they are read. A paragraph is right-to-left when
+// its first letter is.
+func LayoutText(face font.Face, text string, opts LayoutOptions) *TextLayout {
+	face = &advanceCache{Face: face, advances: make(map[rune]fixed.Int26_6)}
+
+	var lines []LineBox
+	for _, paragraph := range strings.Split(text, "\n") {
+		rtl := paragraphRTL(paragraph)
+		for _, line := range wrapParagraph(face, paragraph, opts.MaxWidth) {
+			lines = append(lines, LineBox{Text: line, RTL: rtl})
+		}
+	}
+
+	layout := &TextLayout{}
+	if opts.MaxLines > 0 && len(lines) > opts.MaxLines {
+		lines = lines[:opts.MaxLines]
+		layout.Truncated = true
+		if opts.Ellipsis != "" {
+			last := &lines[len(lines)-1]
+			last.Text = ellipsize(face, last.Text, opts.Ellipsis, opts.MaxWidth)
+		}
+	}
+
+	// Center the ascent and descent within the line height
+	metrics := face.Metrics()
+	ascent, descent := metrics.Ascent.Ceil(), metrics.Descent.Ceil()
+	baselineOffset := (opts.LineHeight-ascent-descent)/2 + ascent
+
+	for i, line := range lines {
+		line.Text = visualOrder(line.Text, line.RTL)
+		width := textWidth(face, line.Text)
+
+		x := (opts.MaxWidth - width) / 2
+		switch resolveAlign(opts.Align, line.RTL) {
+		case AlignLeft:
+			x = 0
+		case AlignRight:
+			x = opts.MaxWidth - width
+		}
+
+		top := i * opts.LineHeight
+		line.Bounds = image.Rect(x, top, x+width, top+opts.LineHeight)
+		line.Baseline = top + baselineOffset
+		layout.Lines = append(layout.Lines, line)
+		layout.Width = max(layout.Width, width)
+	}
+	layout.Height = len(layout.Lines) * opts.LineHeight
+
+	return layout
+}
+
+// resolveAlign turns the start and end alignments into left or right for the direction
+// of a paragraph
+func resolveAlign(align string, rtl bool) string {
+	switch {
+	case align == AlignStart && rtl, align == AlignEnd && !rtl:
+		return AlignRight
+	case align == AlignStart, align == AlignEnd:
+		return AlignLeft
+	}
+	return align
+}
+
+// wrapParagraph breaks a paragraph without newlines into lines that fit maxWidth
+func wrapParagraph(face font.Face, paragraph string, maxWidth int) []string {
+	var lines []string
+	current := ""
+
+	for _, unit := range lineUnits(paragraph) {
+		if current != "" {
+			candidate := current + unit.text
+			if unit.space {
+				candidate = current + " " + unit.text
+			}
+			if textWidth(face, candidate) <= maxWidth {
+				current = candidate
+				continue
+			}
+			lines = append(lines, current)
+			current = ""
+		}
+
+		if textWidth(face, unit.text) <= maxWidth {
+			current = unit.text
+			continue
+		}
+
+		// The unit does not fit on a line of its own, so split it between characters
+		pieces := breakWord(face, unit.text, maxWidth)
+		lines = append(lines, pieces[:len(pieces)-1]...)
+		current = pieces[len(pieces)-1]
+	}
+
+	// Keep empty paragraphs as blank lines
+	return append(lines, current)
+}
+
+// breakWord splits a word into pieces that each fit maxWidth. Pieces end at the last
+// break the kinsoku rules allow, and only break them when there is none. A single
+// character wider than maxWidth still gets a piece of its own.
+func breakWord(face font.Face, word string, maxWidth int) []string {
+	var pieces []string
+	start, allowed := 0, 0
+	prev := rune(-1)
+
+	for i := 0; i < len(word); {
+		r, size := utf8.DecodeRuneInString(word[i:])
+		if i > start && kinsokuAllows(prev, r) {
+			allowed = i
+		}
+		if i > start && textWidth(face, word[start:i+size]) > maxWidth {
+			end := i
+			if !kinsokuAllows(prev, r) && allowed > start {
+				end = allowed
+			}
+			pieces = append(pieces, word[start:end])
+			start = end
+		}
+		prev = r
+		i += size
+	}
+
+	return append(pieces, word[start:])
+}
+
+// ellipsize shortens line from the end until it fits maxWidth with ellipsis appended
+func ellipsize(face font.Face, line, ellipsis string, maxWidth int) string {
+	for line != "" {
+		if candidate := strings.TrimRight(line, " ") + ellipsis; textWidth(face, candidate) <= maxWidth {
+			return candidate
+		}
+		_, size := utf8.DecodeLastRuneInString(line)
+		line = line[:len(line)-size]
+	}
+	return ellipsis
+}
+
+// textWidth returns the advance width of text in whole pixels
+func textWidth(face font.Face, text string) int {
+	return font.MeasureString(face, text).Ceil()
+}
+
+// advanceCache remembers glyph advances, which line breaking measures over and over.
+// Hinted truetype faces load the whole glyph to find its advance.
+type advanceCache struct {
+	font.Face
+	advances map[rune]fixed.Int26_6
+}
+
+// GlyphAdvance returns the advance of r, measuring it on first use
+func (c *advanceCache) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	if advance, ok := c.advances[r]; ok {
+		return advance, true
+	}
+
+	advance, ok := c.Face.GlyphAdvance(r)
+	if ok {
+		c.advances[r] = advance
+	}
+	return advance, ok
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Line break opportunities follow the parts of the Unicode Line Breaking Algorithm
+// (UAX #14) that captions need: lines break at spaces, and between the characters of
+// scripts written without spaces, such as Chinese and Japanese, subject to the kinsoku
+// rules that keep punctuation and brackets with the text they belong to. Latin words
+// only break at spaces.
+
+// lineStartProhibited are the characters a line must not start with: closing brackets
+// and quotes, punctuation that trails what it follows, small kana and iteration marks
+const lineStartProhibited = ")]}»’”〉》」』】〕〗〙〛〞〟｝）］｠｣" +
+	",.:;?!‼⁇⁈⁉、。，．：；？！…‥・ー‐゠–〜～" +
+	"ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶㇰㇱㇲㇳㇴㇵㇶㇷㇸㇹㇺㇻㇼㇽㇾㇿ" +
+	"ゝゞヽヾ々〻%‰℃"
+
+// lineEndProhibited are the characters a line must not end with: opening brackets and quotes
+const lineEndProhibited = "([{«‘“〈《「『【〔〖〘〚〝｛（［｟｢"
+
+// lineUnit is a piece of a paragraph that lines break before and after, never inside
+type lineUnit struct {
+	text  string
+	space bool // A space separates the unit from the one before it
+}
+
+// lineUnits splits a paragraph into the units lines may break between. Runs of
+// whitespace collapse into a single space.
+func lineUnits(paragraph string) []lineUnit {
+	var units []lineUnit
+	for _, word := range strings.Fields(paragraph) {
+		runes := []rune(word)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			if breaksBetween(runes[i-1], runes[i]) {
+				units = append(units, lineUnit{text: string(runes[start:i]), space: start == 0 && len(units) > 0})
+				start = i
+			}
+		}
+		units = append(units, lineUnit{text: string(runes[start:]), space: start == 0 && len(units) > 0})
+	}
+	return units
+}
+
+// breaksBetween reports whether a line may break between two characters that are not
+// separated by a space
+func breaksBetween(before, after rune) bool {
+	if !kinsokuAllows(before, after) {
+		return false
+	}
+	return breaksAround(before) || breaksAround(after)
+}
+
+// kinsokuAllows reports whether a break between two characters keeps the kinsoku rules
+func kinsokuAllows(before, after rune) bool {
+	return !strings.ContainsRune(lineEndProhibited, before) && !strings.ContainsRune(lineStartProhibited, after)
+}
+
+// breaksAround reports whether r is written without spaces, so lines may break on
+// either side of it: ideographs, kana, Hangul, CJK punctuation and emoji
+func breaksAround(r rune) bool {
+	switch {
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Bopomofo):
+		return true
+	case r >= 0x3000 && r <= 0x303F: // CJK symbols and punctuation
+		return true
+	case r >= 0xFF00 && r <= 0xFFEF: // Halfwidth and fullwidth forms
+		return true
+	}
+	return isEmojiRune(r)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"image"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severities of lint issues; only errors fail a lint run
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue is one problem found while linting a template directory
+type LintIssue struct {
+	Template string `json:"template,omitempty"` // Empty for problems not tied to one template
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// LintReport is the result of linting a template directory
+type LintReport struct {
+	TemplateDir string      `json:"template_dir"`
+	Templates   int         `json:"templates"`
+	Errors      int         `json:"errors"`
+	Warnings    int         `json:"warnings"`
+	Issues      []LintIssue `json:"issues"`
+}
+
+// add records an issue and updates the counts
+func (r *LintReport) add(template, severity, message string) {
+	r.Issues = append(r.Issues, LintIssue{Template: template, Severity: severity, Message: message})
+	if severity == LintError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+}
+
+// LintTemplates loads the catalog in dir the way the service does and then checks what
+// loading does not: that every image decodes and has supported dimensions, and that the
+// text regions fit the image and match the declared number of text fields. Checksum
+// mismatches, which only quarantine a template in the service, are errors here, as are
+// missing checksums, which the service only warns about.
+func LintTemplates(dir string, maxDimension int) *LintReport {
+	report := &LintReport{TemplateDir: dir, Issues: []LintIssue{}}
+
+	if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+		report.add("", LintError, fmt.Sprintf("template directory %s not found", dir))
+		return report
+	}
+
+	fsys := os.DirFS(dir)
+	manifest, err := readManifest(fsys, filepath.Join(dir, ManifestFilename))
+	if err != nil {
+		report.add("", LintError, err.Error())
+		return report
+	}
+
+	templates, problems := checkManifest(fsys, manifest)
+	report.Templates = len(templates)
+	for _, problem := range problems {
+		if problem.id != "" {
+			report.add(problem.id, LintError, problem.message)
+		} else {
+			report.add("", LintError, problem.String())
+		}
+	}
+
+	referenced := make(map[string]bool)
+	for _, info := range manifest.Templates {
+		if info == nil || info.Filename == "" || filepath.Base(info.Filename) != info.Filename {
+			continue
+		}
+		referenced[info.Filename] = true
+
+		if info.Quarantined() {
+			report.add(info.ID, LintError, info.quarantine)
+		}
+		if info.unverified {
+			report.add(info.ID, LintError, fmt.Sprintf("sha256 is missing, image %s has sha256 %s", info.Filename, info.SHA256))
+		}
+		for _, issue := range lintTemplate(fsys, info, maxDimension) {
+			report.add(info.ID, issue.Severity, issue.Message)
+		}
+	}
+
+	for _, orphan := range unreferencedImages(fsys, referenced) {
+		report.add("", LintWarning, fmt.Sprintf("image %s is not used by any template", orphan))
+	}
+
+	return report
+}
+
+// lintTemplate decodes the image of a template and checks its dimensions and text regions
+func lintTemplate(fsys fs.FS, info *TemplateInfo, maxDimension int) []LintIssue {
+	var issues []LintIssue
+	report := func(severity, message string) {
+		issues = append(issues, LintIssue{Severity: severity, Message: message})
+	}
+
+	if len(info.TextRegions) > 0 && len(info.TextRegions) != int(info.TextFieldCount) {
+		report(LintError, fmt.Sprintf("text_field_count is %d but %d text regions are declared", info.TextFieldCount, len(info.TextRegions)))
+	}
+
+	file, err := fsys.Open(info.Filename)
+	if err != nil {
+		// A missing image is already reported by the manifest checks
+		return issues
+	}
+	defer file.Close()
+
+	img, format, err := image.Decode(file)
+	if err != nil {
+		report(LintError, fmt.Sprintf("image %s could not be decoded: %v", info.Filename, err))
+		return issues
+	}
+	if _, supported := templateExtensions[format]; !supported {
+		report(LintError, fmt.Sprintf("image %s has unsupported format %s", info.Filename, format))
+	}
+
+	bounds := img.Bounds()
+	if err := checkTemplateDimensions(bounds.Dx(), bounds.Dy(), maxDimension); err != nil {
+		report(LintError, strings.TrimPrefix(err.Error(), ErrInvalidTemplate.Error()+": "))
+	}
+
+	for i, region := range info.TextRegions {
+		ref := fmt.Sprintf("text region %d", i)
+		if region.Name != "" {
+			ref = fmt.Sprintf("text region '%s'", region.Name)
+		}
+
+		if !region.Rect().In(bounds) {
+			report(LintError, fmt.Sprintf("%s (%v) lies outside the %dx%d image", ref, region.Rect(), bounds.Dx(), bounds.Dy()))
+			continue
+		}
+		if region.Rotation != 0 && !rotatedBounds(region).In(bounds) {
+			report(LintWarning, fmt.Sprintf("%s extends outside the image when rotated by %g degrees", ref, region.Rotation))
+		}
+	}
+
+	return issues
+}
+
+// rotatedBounds returns the bounding box of a region after rotation around its center
+func rotatedBounds(region TextRegion) image.Rectangle {
+	theta := region.Rotation * math.Pi / 180
+	halfW, halfH := float64(region.Width)/2, float64(region.Height)/2
+	extentX := math.Abs(halfW*math.Cos(theta)) + math.Abs(halfH*math.Sin(theta))
+	extentY := math.Abs(halfW*math.Sin(theta)) + math.Abs(halfH*math.Cos(theta))
+
+	centerX := float64(region.X) + halfW
+	centerY := float64(region.Y) + halfH
+	return image.Rect(
+		int(math.Floor(centerX-extentX)), int(math.Floor(centerY-extentY)),
+		int(math.Ceil(centerX+extentX)), int(math.Ceil(centerY+extentY)),
+	)
+}
+
+// unreferencedImages lists the image files in fsys that no template uses, ignoring hidden files
+func unreferencedImages(fsys fs.FS, referenced map[string]bool) []string {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil
+	}
+
+	var orphans []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || name == ManifestFilename || referenced[name] {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".jpg", ".jpeg", ".png", ".gif":
+			orphans = append(orphans, name)
+		}
+	}
+	sort.Strings(orphans)
+
+	return orphans
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ManifestFilename is the name of the template manifest inside the template directory
+const ManifestFilename = "manifest.json"
+
+// MaxTextFieldCount is the largest number of text fields a template may declare
+const MaxTextFieldCount = 10
+
+// templateIDPattern restricts template ids to lowercase slugs such as "two-buttons"
+var templateIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// checksumPattern matches a hex-encoded SHA-256
+var checksumPattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// TemplateManifest is the on-disk description of the template catalog
+type TemplateManifest struct {
+	Templates []*TemplateInfo `json:"templates"`
+}
+
+// ManifestError collects every problem found while loading a manifest
+type ManifestError struct {
+	Path     string
+	Problems []string
+}
+
+// templateProblem is a problem with one manifest entry, named by its id or, when it has none, by its position
+type templateProblem struct {
+	id      string
+	entry   int
+	message string
+}
+
+// String formats the problem as it appears in a ManifestError
+func (p templateProblem) String() string {
+	if p.id != "" {
+		return fmt.Sprintf("template '%s': %s", p.id, p.message)
+	}
+	return fmt.Sprintf("entry %d: %s", p.entry, p.message)
+}
+
+// Error implements the error interface
+func (e *ManifestError) Error() string {
+	return fmt.Sprintf("invalid template manifest %s: %s", e.Path, strings.Join(e.Problems, "; "))
+}
+
+// LoadTemplates reads the manifest in dir and returns the declared templates keyed by id.
+// The catalog is only returned if every entry is valid and its image exists. Templates
+// whose image does not match its checksum are included; see Quarantined.
+func LoadTemplates(dir string) (map[string]*TemplateInfo, error) {
+	return loadTemplatesFS(os.DirFS(dir), dir)
+}
+
+// loadTemplatesFS loads the catalog from the manifest at the root of fsys;
+// name identifies fsys in error messages
+func loadTemplatesFS(fsys fs.FS, name string) (map[string]*TemplateInfo, error) {
+	manifestPath := filepath.Join(name, ManifestFilename)
+
+	manifest, err := readManifest(fsys, manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, problems := checkManifest(fsys, manifest)
+	if len(problems) > 0 {
+		manifestErr := &ManifestError{Path: manifestPath}
+		for _, problem := range problems {
+			manifestErr.Problems = append(manifestErr.Problems, problem.String())
+		}
+		return nil, manifestErr
+	}
+
+	return templates, nil
+}
+
+// checkManifest validates the entries of a manifest whose images are stored in fsys
+// and returns the valid templates keyed by id along with every problem found
+func checkManifest(fsys fs.FS, manifest *TemplateManifest) (map[string]*TemplateInfo, []templateProblem) {
+	templates := make(map[string]*TemplateInfo, len(manifest.Templates))
+	var problems []templateProblem
+
+	for i, info := range manifest.Templates {
+		if info == nil {
+			problems = append(problems, templateProblem{entry: i, message: "entry is empty"})
+			continue
+		}
+
+		normalizeTemplate(info)
+
+		report := func(message string) {
+			problems = append(problems, templateProblem{id: info.ID, entry: i, message: message})
+		}
+
+		for _, problem := range validateTemplate(info) {
+			report(problem)
+		}
+
+		if info.Filename != "" && filepath.Base(info.Filename) == info.Filename {
+			stat, err := fs.Stat(fsys, info.Filename)
+			if err != nil {
+				report(fmt.Sprintf("image %s not found", info.Filename))
+			} else if !stat.Mode().IsRegular() {
+				report(fmt.Sprintf("image %s is not a regular file", info.Filename))
+			} else {
+				if info.AddedAt.IsZero() {
+					info.AddedAt = stat.ModTime().UTC()
+				}
+
+				// The version is always derived from the content, never taken from the manifest
+				version, checksum, err := computeTemplateVersion(fsys, info)
+				if err != nil {
+					report(fmt.Sprintf("image %s could not be read: %v", info.Filename, err))
+				}
+				info.Version = version
+				verifyChecksum(info, checksum)
+			}
+		}
+
+		if info.ID == "" {
+			continue
+		}
+		if _, exists := templates[info.ID]; exists {
+			report("duplicate id")
+			continue
+		}
+		templates[info.ID] = info
+	}
+
+	problems = append(problems, checkAliasConflicts(templates)...)
+
+	return templates, problems
+}
+
+// readManifest decodes the manifest in fsys, rejecting unknown fields so typos are caught early
+func readManifest(fsys fs.FS, path string) (*TemplateManifest, error) {
+	file, err := fsys.Open(ManifestFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open template manifest: %v", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	var manifest TemplateManifest
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, &ManifestError{Path: path, Problems: []string{err.Error()}}
+	}
+
+	return &manifest, nil
+}
+
+// validateTemplate checks the fields of a single manifest entry
+func validateTemplate(info *TemplateInfo) []string {
+	problems := validateTemplateMetadata(info)
+
+	if info.Filename == "" {
+		problems = append(problems, "filename is required")
+	} else if filepath.Base(info.Filename) != info.Filename {
+		problems = append(problems, fmt.Sprintf("filename %s must not contain a directory", info.Filename))
+	}
+
+	if info.SHA256 != "" && !checksumPattern.MatchString(info.SHA256) {
+		problems = append(problems, "sha256 must be 64 hexadecimal characters")
+	}
+
+	return problems
+}
+
+// validateTemplateMetadata checks the descriptive fields of a template, everything but its image
+func validateTemplateMetadata(info *TemplateInfo) []string {
+	var problems []string
+
+	if info.ID == "" {
+		problems = append(problems, "id is required")
+	} else if !templateIDPattern.MatchString(info.ID) {
+		problems = append(problems, "id must contain only lowercase letters, digits and single dashes")
+	}
+
+	if strings.TrimSpace(info.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+
+	if info.TextFieldCount < 1 || info.TextFieldCount > MaxTextFieldCount {
+		problems = append(problems, fmt.Sprintf("text_field_count must be between 1 and %d", MaxTextFieldCount))
+	}
+
+	problems = append(problems, validateLabels(info)...)
+	problems = append(problems, validateRegions(info.TextRegions)...)
+	problems = append(problems, validateTextStyle("text_style", info.TextStyle)...)
+
+	if len(info.ExampleCaptions) > int(info.TextFieldCount) {
+		problems = append(problems, fmt.Sprintf("example_captions has %d captions but the template has %d text fields", len(info.ExampleCaptions), info.TextFieldCount))
+	}
+
+	return problems
+}
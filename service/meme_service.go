@@ -4,38 +4,98 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"image"
-	"image/color"
 	"image/draw"
 	"image/jpeg"
-	"io/ioutil"
+	"io/fs"
 	"log"
-	"os"
-	"path/filepath"
-	"strings"
+	"sort"
+	"sync"
+	"time"
 
 	pb "github.com/RoMalms10/grpc/meme"
 	"github.com/RoMalms10/meme-generator/config"
-	"github.com/golang/freetype"
-	"github.com/golang/freetype/truetype"
-	"golang.org/x/image/font"
 )
 
 // MemeService configuration values will be provided via config package
 
 // TemplateInfo represents information about a meme template
 type TemplateInfo struct {
-	Name           string
-	TextFieldCount int32
-	Category       string
-	Filename       string
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	TextFieldCount int32  `json:"text_field_count"`
+	Category       string `json:"category"`
+	Filename       string `json:"filename"`
+
+	// Categories, Tags and Aliases are optional labels. Category stays the primary
+	// category; aliases are alternative ids accepted by GenerateMeme.
+	Categories []string `json:"categories,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Aliases    []string `json:"aliases,omitempty"`
+
+	// Keywords are extra search terms; AddedAt defaults to the image modification time
+	Keywords []string  `json:"keywords,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
+
+	// TextRegions places each caption in its own box; templates without
+	// regions use the classic top/bottom layout
+	TextRegions []TextRegion `json:"text_regions,omitempty"`
+
+	// TextStyle overrides the default white text with a black outline
+	TextStyle *TextStyle `json:"text_style,omitempty"`
+
+	// ExampleCaptions show how the template is meant to be used, one per text field,
+	// in previews with captions and in the gallery
+	ExampleCaptions []string `json:"example_captions,omitempty"`
+
+	// Version is a content hash of the image and layout, computed on load. Every
+	// published version is archived so GenerateMemeVersion can render it later.
+	Version string `json:"version,omitempty"`
+
+	// SHA256 is the checksum of the image. A declared checksum is verified on load and
+	// templates whose image does not match are quarantined; a missing one is filled in
+	// from the image, reported as unverified, and recorded the next time the manifest is
+	// written.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// quarantine is the reason the template failed verification; empty for healthy templates
+	quarantine string
+
+	// unverified is set when the manifest records no checksum, so the image was trusted as it was
+	unverified bool
+
+	// images holds the image of a built-in or archived template; nil means the template directory
+	images fs.FS
 }
 
 // MemeService handles the business logic for meme generation
 type MemeService struct {
+	// Templates is the current catalog. It is replaced as a whole on reload and
+	// must only be accessed through the locking helpers once the service is running.
 	Templates map[string]*TemplateInfo
 	Config    *config.Config
+
+	// quarantined holds the templates that failed verification, keyed by id.
+	// They are kept out of the catalog but stay in the manifest.
+	quarantined map[string]*TemplateInfo
+
+	mu      sync.RWMutex
+	storeMu sync.Mutex // Serializes changes to the template store
+
+	previewMu sync.Mutex
+	previews  map[previewKey]cachedPreview
+
+	statsMu    sync.Mutex
+	usage      map[string]*usageCounter
+	usageDirty bool
+
+	fontFallbackOnce sync.Once
+
+	fontsMu sync.Mutex
+	fonts   *fontRegistry // Loaded on first use and dropped on reload
+	emoji   *emojiSet     // Likewise
 }
 
 // NewMemeService creates a new instance of the meme service
@@ -43,88 +103,145 @@ func NewMemeService() *MemeService {
 	// Load configuration
 	cfg := config.LoadConfig()
 
-	// Initialize with some default templates
-	templates := map[string]*TemplateInfo{
-		"drake": {
-			Name:           "Drake Hotline Bling",
-			TextFieldCount: 2,
-			Category:       "classic",
-			Filename:       "drake.jpg",
-		},
-		"distracted-boyfriend": {
-			Name:           "Distracted Boyfriend",
-			TextFieldCount: 3,
-			Category:       "classic",
-			Filename:       "distracted-boyfriend.jpg",
-		},
-		"two-buttons": {
-			Name:           "Two Buttons",
-			TextFieldCount: 3,
-			Category:       "classic",
-			Filename:       "two-buttons.jpg",
-		},
-		"change-my-mind": {
-			Name:           "Change My Mind",
-			TextFieldCount: 1,
-			Category:       "debate",
-			Filename:       "change-my-mind.jpg",
-		},
+	// Load the template catalog from the manifest in the template directory,
+	// or from the built-in pack when there is no template directory
+	templates, err := loadCatalog(cfg.TemplateDir)
+	if err != nil {
+		log.Printf("Warning: failed to load templates from %s: %v", cfg.TemplateDir, err)
+		templates = map[string]*TemplateInfo{}
 	}
+	s := &MemeService{Config: cfg}
+	s.publishCatalog(templates)
+	log.Printf("Loaded %d templates from %s", len(s.TemplateCatalog()), cfg.TemplateDir)
 
-	return &MemeService{
-		Templates: templates,
-		Config:    cfg,
+	// Usage statistics survive restarts through the stats file
+	if err := s.LoadUsageStats(); err != nil {
+		log.Printf("Warning: %v", err)
 	}
+
+	return s
 }
 
-// GenerateMeme creates a meme with the given parameters
+// GenerateMeme creates a meme with the given parameters from the current template version
 func (s *MemeService) GenerateMeme(ctx context.Context, req *pb.GenerateMemeRequest) (*pb.GenerateMemeResponse, error) {
-	log.Printf("Service: Processing meme generation for template: %s", req.TemplateId)
+	resp, _, err := s.GenerateMemeVersion(ctx, req, "")
+	return resp, err
+}
 
-	// Check if the template exists
-	_, exists := s.Templates[req.TemplateId]
-	if !exists {
+// GenerateMemeVersion creates a meme from the given template version, or from the current
+// version when version is empty. It also returns the version that was rendered.
+func (s *MemeService) GenerateMemeVersion(ctx context.Context, req *pb.GenerateMemeRequest, version string) (*pb.GenerateMemeResponse, string, error) {
+	meme, err := s.Generate(ctx, &MemeRequest{
+		TemplateID:      req.TemplateId,
+		TemplateVersion: version,
+		Captions:        captionsFromText(req.TopText, req.BottomText, req.AdditionalText),
+		UseAICaption:    req.UseAiCaption,
+	})
+	switch {
+	case errors.Is(err, ErrVersionNotFound):
+		return &pb.GenerateMemeResponse{
+			Error: fmt.Sprintf("Template '%s' has no version '%s'", req.TemplateId, version),
+		}, "", nil
+	case errors.Is(err, ErrTemplateNotFound):
 		return &pb.GenerateMemeResponse{
 			Error: fmt.Sprintf("Template '%s' not found", req.TemplateId),
-		}, nil
+		}, "", nil
+	case err != nil:
+		return &pb.GenerateMemeResponse{
+			Error: fmt.Sprintf("Failed to generate meme: %v", err),
+		}, "", nil
+	}
+
+	return &pb.GenerateMemeResponse{
+		ImageData:         base64.StdEncoding.EncodeToString(meme.ImageData),
+		MimeType:          meme.MimeType,
+		GeneratedCaptions: meme.GeneratedCaptions,
+		Error:             "",
+	}, meme.TemplateVersion, nil
+}
+
+// Generate creates a meme with per-caption options from the requested template version,
+// or from the current version when the request names none
+func (s *MemeService) Generate(ctx context.Context, req *MemeRequest) (*GeneratedMeme, error) {
+	log.Printf("Service: Processing meme generation for template: %s, version: %q", req.TemplateID, req.TemplateVersion)
+
+	if err := s.validateCaptions(req.Captions); err != nil {
+		return nil, err
+	}
+	if err := s.validateLayout(req); err != nil {
+		return nil, err
+	}
+
+	// Check if the template exists, accepting aliases such as "hotline-bling" for "drake"
+	template, id, exists := s.ResolveTemplate(req.TemplateID)
+	if req.TemplateVersion != "" {
+		// Archived versions of deleted templates can still be rendered by id
+		if !exists {
+			id = req.TemplateID
+		}
+		pinned, err := s.templateAtVersion(id, req.TemplateVersion)
+		if err != nil {
+			if !errors.Is(err, ErrVersionNotFound) {
+				log.Printf("Error loading template version: %v", err)
+			}
+			return nil, fmt.Errorf("%w: template '%s' has no version '%s'", ErrVersionNotFound, req.TemplateID, req.TemplateVersion)
+		}
+		template, exists = pinned, true
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, req.TemplateID)
 	}
 
+	// Copy the captions so the request is left as it was
+	captions := append([]Caption(nil), req.Captions...)
+
 	// Handle AI caption generation if requested
 	var generatedCaptions []string
-	if req.UseAiCaption {
+	if req.UseAICaption {
 		// In a real implementation, you'd call an AI service here
 		// For now, we'll just generate something simple based on the template
-		template := s.Templates[req.TemplateId]
 		generatedCaptions = []string{
 			fmt.Sprintf("AI generated caption for %s meme", template.Name),
 		}
 
 		// Use the generated caption if no text was provided
-		if req.TopText == "" && len(generatedCaptions) > 0 {
-			req.TopText = generatedCaptions[0]
+		if captionText(captions, 0) == "" && len(generatedCaptions) > 0 {
+			if len(captions) == 0 {
+				captions = append(captions, Caption{})
+			}
+			captions[0].Text = generatedCaptions[0]
 		}
 	}
 
 	// Generate the meme image
-	imageData, mimeType, err := s.generateMemeImage(
-		req.TemplateId,
-		req.TopText,
-		req.BottomText,
-		req.AdditionalText,
-	)
-
+	var memeImg *image.RGBA
+	var err error
+	if req.Layout == LayoutCaptionBar {
+		memeImg, err = s.renderCaptionBars(template, captions, req.CaptionBar)
+	} else {
+		memeImg, err = s.renderMeme(template, captions, s.Config.TextOverflow)
+	}
 	if err != nil {
 		log.Printf("Error generating meme: %v", err)
-		return &pb.GenerateMemeResponse{
-			Error: fmt.Sprintf("Failed to generate meme: %v", err),
-		}, nil
+		return nil, err
+	}
+	imageData, mimeType, err := s.encodeMemeImage(memeImg)
+	if err != nil {
+		log.Printf("Error generating meme: %v", err)
+		return nil, err
 	}
 
-	return &pb.GenerateMemeResponse{
+	// Count usage under the canonical id so aliases add up
+	s.RecordUsage(id, time.Now())
+
+	return &GeneratedMeme{
 		ImageData:         imageData,
 		MimeType:          mimeType,
+		TemplateID:        id,
+		TemplateVersion:   template.Version,
+		Width:             memeImg.Bounds().Dx(),
+		Height:            memeImg.Bounds().Dy(),
 		GeneratedCaptions: generatedCaptions,
-		Error:             "",
 	}, nil
 }
 
@@ -132,47 +249,102 @@ func (s *MemeService) GenerateMeme(ctx context.Context, req *pb.GenerateMemeRequ
 func (s *MemeService) ListTemplates(ctx context.Context, req *pb.ListTemplatesRequest) (*pb.ListTemplatesResponse, error) {
 	log.Printf("Service: Listing templates with category filter: %s", req.Category)
 
-	var templates []*pb.Template
+	var matches []*TemplateInfo
 
-	for id, info := range s.Templates {
-		// Apply category filter if specified
-		if req.Category != "" && info.Category != req.Category {
+	for id, info := range s.TemplateCatalog() {
+		// Apply category filter if specified; it matches any category or tag
+		if req.Category != "" && !info.HasLabel(req.Category) {
 			continue
 		}
 
+		match := *info
+		match.ID = id
+		matches = append(matches, &match)
+	}
+
+	// Map iteration order is random, so sort for a stable response
+	sort.Slice(matches, func(i, j int) bool {
+		return templateOrders[SortByName](matches[i], matches[j])
+	})
+
+	return &pb.ListTemplatesResponse{
+		Templates: protoTemplates(matches),
+	}, nil
+}
+
+// ListTemplatesPage returns one page of the templates matching both the category filter of
+// a ListTemplates request and query, along with the token of the next page
+func (s *MemeService) ListTemplatesPage(ctx context.Context, req *pb.ListTemplatesRequest, query *TemplateQuery) (*pb.ListTemplatesResponse, string, error) {
+	log.Printf("Service: Listing templates with category filter: %s, query: %q, sort: %q", req.Category, query.Text, query.SortBy)
+
+	filtered := *query
+	filtered.Label = req.Category
+	page, err := s.SearchTemplates(&filtered)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &pb.ListTemplatesResponse{
+		Templates: protoTemplates(page.Templates),
+	}, page.NextPageToken, nil
+}
+
+// protoTemplates converts catalog entries to their ListTemplates form
+func protoTemplates(infos []*TemplateInfo) []*pb.Template {
+	templates := make([]*pb.Template, 0, len(infos))
+	for _, info := range infos {
 		templates = append(templates, &pb.Template{
-			Id:             id,
+			Id:             info.ID,
 			Name:           info.Name,
 			TextFieldCount: info.TextFieldCount,
 			Category:       info.Category,
-			PreviewUrl:     fmt.Sprintf("/templates/%s", info.Filename),
+			PreviewUrl:     info.PreviewURL(),
 		})
 	}
+	return templates
+}
 
-	return &pb.ListTemplatesResponse{
-		Templates: templates,
-	}, nil
+// PreviewURL returns the path at which the template thumbnail is served
+func (t *TemplateInfo) PreviewURL() string {
+	return fmt.Sprintf("/templates/%s", t.Filename)
 }
 
-// generateMemeImage creates a meme image with the given template and text
-func (s *MemeService) generateMemeImage(templateID, topText, bottomText string, additionalText []string) (string, string, error) {
-	template, exists := s.Templates[templateID]
-	if !exists {
-		return "", "", fmt.Errorf("template '%s' not found", templateID)
+// encodeMemeImage encodes a rendered meme and returns it with its MIME type
+func (s *MemeService) encodeMemeImage(memeImg *image.RGBA) ([]byte, string, error) {
+	// Encode the image to JPEG
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, memeImg, &jpeg.Options{Quality: s.Config.ImageQuality})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %v", err)
 	}
 
-	// Load the template image
-	imgPath := filepath.Join(s.Config.TemplateDir, template.Filename)
-	imgFile, err := os.Open(imgPath)
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// loadTemplateImage decodes the image of a template
+func (s *MemeService) loadTemplateImage(template *TemplateInfo) (image.Image, error) {
+	imgFile, err := s.templateImages(template).Open(template.Filename)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to open template image: %v", err)
+		return nil, fmt.Errorf("failed to open template image: %v", err)
 	}
 	defer imgFile.Close()
 
 	// Decode the image
 	img, _, err := image.Decode(imgFile)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to decode template image: %v", err)
+		return nil, fmt.Errorf("failed to decode template image: %v", err)
+	}
+
+	return img, nil
+}
+
+// renderMeme draws the captions onto a copy of the template image. Captions fill the
+// text fields in order: top text, bottom text, then additional text. Captions that do not
+// fit are handled by the given overflow policy.
+func (s *MemeService) renderMeme(template *TemplateInfo, captions []Caption, overflow string) (*image.RGBA, error) {
+	img, err := s.loadTemplateImage(template)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create a new RGBA image
@@ -183,169 +355,100 @@ func (s *MemeService) generateMemeImage(templateID, topText, bottomText string,
 	draw.Draw(memeImg, bounds, img, bounds.Min, draw.Src)
 
 	// Load the font
-	fontData, err := ioutil.ReadFile(s.Config.FontFile)
-	if err != nil {
-		// Fallback to default font if custom font can't be loaded
-		// In a real implementation, you'd embed the font or handle this better
-		return "", "", fmt.Errorf("failed to load font: %v", err)
-	}
-
-	f, err := freetype.ParseFont(fontData)
+	f, err := s.templateFont(template)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to parse font: %v", err)
+		return nil, err
 	}
-
-	// Set up the context for drawing text
-	c := freetype.NewContext()
-	c.SetDPI(72)
-	c.SetFont(f)
-	c.SetClip(bounds)
-	c.SetDst(memeImg)
-	c.SetHinting(font.HintingFull)
-	c.SetSrc(image.NewUniform(color.White))
+	style := template.renderStyle()
 
 	// Get image dimensions
 	imgWidth := bounds.Dx()
 	imgHeight := bounds.Dy()
 
-	// Adjust font size based on image dimensions
-	dynamicFontSize := float64(imgWidth) / 12
-	if dynamicFontSize < 16 {
-		dynamicFontSize = 16
-	} else if dynamicFontSize > 48 {
-		dynamicFontSize = 48
-	}
-	c.SetFontSize(dynamicFontSize)
+	// Start from a font size that scales with the image width
+	minSize, maxSize := s.fontSizeLimits()
+	fontSize := min(max(float64(imgWidth)/12, minSize), maxSize)
 
-	// Draw top text
-	if topText != "" {
-		s.drawTextWithStroke(c, topText, imgWidth/2, int(dynamicFontSize*1.5), imgWidth, f)
+	if len(template.TextRegions) > 0 {
+		for i, region := range template.TextRegions {
+			if captionText(captions, i) == "" {
+				continue
+			}
+			caption, err := s.styleCaption(captions[i], style.withAlign(region.Align), f)
+			if err != nil {
+				return nil, err
+			}
+			caption.overflow = overflow
+			if err := s.drawTextInRegion(memeImg, caption, region, fontSize); err != nil {
+				return nil, err
+			}
+		}
+		return memeImg, nil
 	}
 
-	// Draw bottom text
-	if bottomText != "" {
-		s.drawTextWithStroke(c, bottomText, imgWidth/2, imgHeight-int(dynamicFontSize*1.5), imgWidth, f)
+	// Without regions, captions span the image width. Top and bottom text each get half of
+	// the height, or a third when additional text is stacked in the middle.
+	middle := min(max(len(captions)-2, 0), max(int(template.TextFieldCount)-2, 0))
+	margin := int(fontSize / 2)
+	edgeHeight := imgHeight/2 - margin
+	if middle > 0 {
+		edgeHeight = imgHeight/3 - margin
 	}
-
-	// Handle additional text for multi-panel memes
-	for i, text := range additionalText {
-		if i >= int(template.TextFieldCount)-2 {
-			break // Only use as many text fields as the template supports
+	fitCaption := func(i int, box captionBox) (*fittedCaption, styledCaption, error) {
+		caption, err := s.styleCaption(captions[i], style, f)
+		if err != nil {
+			return nil, caption, err
 		}
-
-		// Position additional text fields based on template and panel count
-		// This is a simplified approach; real implementation would be template-specific
-		x := imgWidth / 2
-		y := imgHeight/2 + (i-1)*int(dynamicFontSize*2)
-
-		s.drawTextWithStroke(c, text, x, y, imgWidth, f)
+		caption.overflow = overflow
+		fit, err := s.fitCaption(caption, box)
+		return fit, caption, err
 	}
 
-	// Encode the image to JPEG
-	var buf bytes.Buffer
-	err = jpeg.Encode(&buf, memeImg, &jpeg.Options{Quality: s.Config.ImageQuality})
-	if err != nil {
-		return "", "", fmt.Errorf("failed to encode image: %v", err)
+	// Top text grows down from the top edge
+	if captionText(captions, 0) != "" {
+		fit, caption, err := fitCaption(0, captionBox{name: "top text", width: imgWidth, height: edgeHeight, size: fontSize})
+		if err != nil {
+			return nil, err
+		}
+		drawTextWithStroke(memeImg, fit.face, fit.layout, image.Pt(bounds.Min.X+fit.padding, bounds.Min.Y+margin), caption.style, fit.size)
 	}
 
-	// Return base64 encoded image
-	return base64.StdEncoding.EncodeToString(buf.Bytes()), "image/jpeg", nil
-}
-
-// drawTextWithStroke draws text with a black outline
-func (s *MemeService) drawTextWithStroke(c *freetype.Context, text string, x, y, maxWidth int, f *truetype.Font) {
-	// Get the current font size from the context
-	fontSize := s.Config.FontSize
-	lineSpacing := s.Config.LineSpacing
-
-	// Split the text into lines if it's too long
-	lines := []string{text}
-	if len(text)*int(c.PointToFixed(fontSize)>>6)/2 > maxWidth {
-		// Split text into multiple lines
-		words := strings.Split(text, " ")
-		currentLine := ""
-		lines = []string{}
-
-		for _, word := range words {
-			testLine := currentLine
-			if testLine != "" {
-				testLine += " "
-			}
-			testLine += word
-
-			// Measure the width of the test line
-			opts := truetype.Options{
-				Size: fontSize,
-			}
-			face := truetype.NewFace(f, &opts)
-			width := font.MeasureString(face, testLine)
-
-			if width.Ceil() < maxWidth-20 {
-				currentLine = testLine
-			} else {
-				if currentLine != "" {
-					lines = append(lines, currentLine)
-				}
-				currentLine = word
-			}
-		}
-		if currentLine != "" {
-			lines = append(lines, currentLine)
+	// Bottom text grows up from the bottom edge
+	if captionText(captions, 1) != "" {
+		fit, caption, err := fitCaption(1, captionBox{name: "bottom text", width: imgWidth, height: edgeHeight, size: fontSize})
+		if err != nil {
+			return nil, err
 		}
+		drawTextWithStroke(memeImg, fit.face, fit.layout, image.Pt(bounds.Min.X+fit.padding, bounds.Max.Y-margin-fit.layout.Height), caption.style, fit.size)
 	}
 
-	// Calculate y position for all lines
-	lineHeight := int(c.PointToFixed(fontSize*lineSpacing) >> 6)
-	startY := y - (len(lines)-1)*lineHeight/2
-
-	// Draw each line
-	for i, line := range lines {
-		// Set up for measuring text width
-		opts := truetype.Options{
-			Size: fontSize,
+	// Additional text for multi-panel memes shares the middle third, one slot per caption,
+	// using only as many text fields as the template supports
+	for i := 0; i < middle; i++ {
+		if captionText(captions, i+2) == "" {
+			continue
 		}
-		face := truetype.NewFace(f, &opts)
-		width := font.MeasureString(face, line)
 
-		// Center text horizontally
-		textX := x - width.Ceil()/2
-		textY := startY + i*lineHeight
-
-		// Draw text outline (stroke)
-		strokeSize := int(fontSize / 6)
-		if strokeSize < 1 {
-			strokeSize = 1
+		slotHeight := imgHeight / (3 * middle)
+		fit, caption, err := fitCaption(i+2, captionBox{
+			name:   fmt.Sprintf("additional text %d", i+1),
+			width:  imgWidth,
+			height: slotHeight,
+			size:   fontSize,
+		})
+		if err != nil {
+			return nil, err
 		}
 
-		// Save current color (need to recreate it since we can't access it directly)
-		origColor := image.NewUniform(color.White)
-
-		// Set color for outline
-		blackColor := image.NewUniform(color.Black)
-		c.SetSrc(blackColor)
-
-		// Draw outline by shifting text position slightly in all directions
-		for dy := -strokeSize; dy <= strokeSize; dy++ {
-			for dx := -strokeSize; dx <= strokeSize; dx++ {
-				if dx == 0 && dy == 0 {
-					continue // Skip the center position
-				}
-				pt := freetype.Pt(textX+dx, textY+dy)
-				_, err := c.DrawString(line, pt)
-				if err != nil {
-					continue
-				}
-			}
-		}
+		centerY := bounds.Min.Y + imgHeight/3 + i*slotHeight + slotHeight/2
+		drawTextWithStroke(memeImg, fit.face, fit.layout, image.Pt(bounds.Min.X+fit.padding, centerY-fit.layout.Height/2), caption.style, fit.size)
+	}
 
-		// Restore original color for main text
-		c.SetSrc(origColor)
+	return memeImg, nil
+}
 
-		// Draw the main text
-		pt := freetype.Pt(textX, textY)
-		_, err := c.DrawString(line, pt)
-		if err != nil {
-			continue
-		}
-	}
+// captionPadding is the horizontal space kept between a caption and the edge of its box,
+// wide enough for the outline
+func captionPadding(fontSize float64, style renderStyle) int {
+	return int(fontSize/4) + style.outlineWidth(fontSize)
 }
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// drawTextWithStroke draws a text layout with its top-left corner at origin, in the fill
+// colour of style over an outline in its stroke colour. The outline width is derived from
+// fontSize unless the style sets one. The style's shadow and glow go under the outline.
+//
+// The text is rasterized once into a coverage mask. The outline is that mask grown by the
+// stroke width, with round corners and anti-aliased edges, so its cost does not depend on
+// the width.
+func drawTextWithStroke(dst draw.Image, face font.Face, layout *TextLayout, origin image.Point, style renderStyle, fontSize float64) {
+	strokeSize := style.outlineWidth(fontSize)
+
+	// Only the part of the text that can reach dst, directly or through its effects, needs a mask
+	margin := strokeSize + 1 + style.effectMargin()
+	area := textBounds(face, layout, origin).Inset(-margin).Intersect(dst.Bounds().Inset(-margin))
+	if area.Empty() {
+		return
+	}
+
+	mask := image.NewAlpha(area)
+	drawer := &font.Drawer{Dst: mask, Src: image.Opaque, Face: face}
+	for _, line := range layout.Lines {
+		drawer.Dot = fixed.P(origin.X+line.Bounds.Min.X, origin.Y+line.Baseline)
+		drawer.DrawString(line.Text)
+	}
+
+	silhouette := mask
+	if strokeSize > 0 {
+		silhouette = dilate(mask, strokeSize)
+	}
+
+	if style.shadow != nil {
+		drawEffect(dst, area, silhouette, style.shadow)
+	}
+	if style.glow != nil {
+		drawEffect(dst, area, silhouette, style.glow)
+	}
+	if strokeSize > 0 {
+		draw.DrawMask(dst, area, image.NewUniform(style.stroke), image.Point{}, silhouette, area.Min, draw.Over)
+	}
+	draw.DrawMask(dst, area, image.NewUniform(style.fill), image.Point{}, mask, area.Min, draw.Over)
+
+	// Emoji are not in the mask; they keep their own colours and get no outline
+	if emoji, ok := face.(*emojiFace); ok {
+		emoji.drawSprites(dst, layout, origin)
+	}
+}
+
+// textBounds returns the rectangle covered by the glyphs of a layout drawn at origin
+func textBounds(face font.Face, layout *TextLayout, origin image.Point) image.Rectangle {
+	var bounds image.Rectangle
+	for _, line := range layout.Lines {
+		ink, _ := font.BoundString(face, line.Text)
+		rect := image.Rect(ink.Min.X.Floor(), ink.Min.Y.Floor(), ink.Max.X.Ceil(), ink.Max.Y.Ceil())
+		bounds = bounds.Union(rect.Add(image.Pt(origin.X+line.Bounds.Min.X, origin.Y+line.Baseline)))
+	}
+	return bounds
+}
+
+// dilate grows the coverage of mask by radius pixels in every direction. Pixels at least
+// half covered count as inside; every other pixel is covered by how far it lies within
+// radius of the nearest of them, which rounds the corners and anti-aliases the edge.
+func dilate(mask *image.Alpha, radius int) *image.Alpha {
+	bounds := mask.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Squared distance of every pixel to the nearest inside pixel
+	dist := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if mask.Pix[y*mask.Stride+x] >= 0x80 {
+				dist[y*width+x] = 0
+			} else {
+				dist[y*width+x] = distanceInfinity
+			}
+		}
+	}
+	transform := newDistanceTransform(max(width, height))
+	for x := 0; x < width; x++ {
+		transform.run(dist[x:], width, height)
+	}
+	for y := 0; y < height; y++ {
+		transform.run(dist[y*width:], 1, width)
+	}
+
+	outline := image.NewAlpha(bounds)
+	edge := float64(radius) + 0.5
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			coverage := math.Min(math.Max(edge-math.Sqrt(dist[y*width+x]), 0), 1)
+			outline.Pix[y*outline.Stride+x] = max(uint8(coverage*0xff), mask.Pix[y*mask.Stride+x])
+		}
+	}
+
+	return outline
+}
+
+// distanceInfinity stands in for the distance to an inside pixel when there is none.
+// It is finite so the parabola intersections below stay well defined.
+const distanceInfinity = 1e20
+
+// distanceTransform computes one-dimensional squared Euclidean distance transforms, the
+// lower envelope of parabolas rooted at each sample (Felzenszwalb and Huttenlocher).
+// Running it over every column and then every row gives the two-dimensional transform.
+type distanceTransform struct {
+	f, d []float64 // Input samples and output distances
+	v    []int     // Positions of the parabolas in the lower envelope
+	z    []float64 // Boundaries between the parabolas of the envelope
+}
+
+// newDistanceTransform allocates the buffers for transforms of up to n samples
+func newDistanceTransform(n int) *distanceTransform {
+	return &distanceTransform{
+		f: make([]float64, n),
+		d: make([]float64, n),
+		v: make([]int, n),
+		z: make([]float64, n+1),
+	}
+}
+
+// run transforms the n samples of data that are stride apart, in place
+func (t *distanceTransform) run(data []float64, stride, n int) {
+	f, d, v, z := t.f[:n], t.d[:n], t.v, t.z
+	for i := range f {
+		f[i] = data[i*stride]
+	}
+
+	k := 0
+	v[0] = 0
+	z[0], z[1] = math.Inf(-1), math.Inf(1)
+	for q := 1; q < n; q++ {
+		s := t.intersection(q, v[k])
+		for s <= z[k] {
+			k--
+			s = t.intersection(q, v[k])
+		}
+		k++
+		v[k] = q
+		z[k], z[k+1] = s, math.Inf(1)
+	}
+
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		p := v[k]
+		d[q] = float64((q-p)*(q-p)) + f[p]
+	}
+
+	for i, value := range d {
+		data[i*stride] = value
+	}
+}
+
+// intersection returns where the parabolas rooted at samples q and p cross. The first
+// boundary of the envelope is minus infinity, so the search in run never passes it.
+func (t *distanceTransform) intersection(q, p int) float64 {
+	return ((t.f[q] + float64(q*q)) - (t.f[p] + float64(p*p))) / float64(2*(q-p))
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io/fs"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// PreviewSizes maps the supported thumbnail sizes to their longest side in pixels
+var PreviewSizes = map[string]int{
+	"small":  160,
+	"medium": 320,
+	"large":  640,
+}
+
+// DefaultPreviewSize is used when a preview request does not name a size
+const DefaultPreviewSize = "medium"
+
+// ErrUnknownPreviewSize is returned for sizes not listed in PreviewSizes
+var ErrUnknownPreviewSize = errors.New("unknown preview size")
+
+// previewKey identifies a cached thumbnail
+type previewKey struct {
+	filename string
+	size     string
+	captions bool
+}
+
+// cachedPreview is a rendered thumbnail along with the template and image state it was made from
+type cachedPreview struct {
+	template *TemplateInfo
+	stamp    string
+	data     []byte
+}
+
+// TemplatePreview returns a JPEG thumbnail of the template stored in filename.
+// With captions set, each text field is filled with a placeholder caption.
+// Thumbnails are rendered on first use and cached until the template image changes.
+func (s *MemeService) TemplatePreview(filename, size string, captions bool) ([]byte, error) {
+	if size == "" {
+		size = DefaultPreviewSize
+	}
+	maxSide, supported := PreviewSizes[size]
+	if !supported {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownPreviewSize, size)
+	}
+
+	template, exists := s.templateByFilename(filename)
+	if !exists {
+		return nil, fmt.Errorf("%w: no template uses %s", ErrTemplateNotFound, filename)
+	}
+
+	// Catalog entries are replaced rather than modified, so a cached thumbnail is valid
+	// while both the entry and the image file are unchanged
+	stat, err := fs.Stat(s.templateImages(template), template.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open template image: %v", err)
+	}
+	stamp := fmt.Sprintf("%d-%d", stat.Size(), stat.ModTime().UnixNano())
+	key := previewKey{filename: filename, size: size, captions: captions}
+
+	s.previewMu.Lock()
+	cached, found := s.previews[key]
+	s.previewMu.Unlock()
+	if found && cached.template == template && cached.stamp == stamp {
+		return cached.data, nil
+	}
+
+	var img image.Image
+	if captions {
+		top, bottom, additional := placeholderCaptions(template)
+		// A preview shows the template rather than validating its captions, so long
+		// example captions are cut short whatever TEXT_OVERFLOW says
+		img, err = s.renderMeme(template, captionsFromText(top, bottom, additional), OverflowEllipsis)
+	} else {
+		img, err = s.loadTemplateImage(template)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail(img, maxSide), &jpeg.Options{Quality: s.Config.ImageQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode preview: %v", err)
+	}
+
+	s.previewMu.Lock()
+	// Skip caching when the template was replaced or deleted while rendering, since the
+	// catalog change has already pruned the cache and nothing would remove this entry
+	if current, exists := s.templateByFilename(filename); exists && current == template {
+		if s.previews == nil {
+			s.previews = make(map[previewKey]cachedPreview)
+		}
+		s.previews[key] = cachedPreview{template: template, stamp: stamp, data: buf.Bytes()}
+	}
+	s.previewMu.Unlock()
+
+	return buf.Bytes(), nil
+}
+
+// prunePreviews removes the cached thumbnails of templates that are not in catalog. A
+// replaced or deleted entry would never be served from the cache again, so keeping its
+// thumbnails only grows the cache.
+func (s *MemeService) prunePreviews(catalog map[string]*TemplateInfo) {
+	current := make(map[*TemplateInfo]bool, len(catalog))
+	for _, info := range catalog {
+		current[info] = true
+	}
+
+	s.previewMu.Lock()
+	defer s.previewMu.Unlock()
+	for key, cached := range s.previews {
+		if !current[cached.template] {
+			delete(s.previews, key)
+		}
+	}
+}
+
+// templateByFilename finds the template whose image is stored in filename
+func (s *MemeService) templateByFilename(filename string) (*TemplateInfo, bool) {
+	for _, info := range s.TemplateCatalog() {
+		if info.Filename == filename {
+			return info, true
+		}
+	}
+	return nil, false
+}
+
+// placeholderCaptions fills every text field of a template with a sample caption: its
+// example caption when it has one, otherwise the region name or the field position
+func placeholderCaptions(template *TemplateInfo) (string, string, []string) {
+	// Always allocate the top and bottom captions, even for single-field templates
+	captions := make([]string, max(int(template.TextFieldCount), 2))
+	for i := 0; i < int(template.TextFieldCount); i++ {
+		if i < len(template.ExampleCaptions) && template.ExampleCaptions[i] != "" {
+			captions[i] = template.ExampleCaptions[i]
+			continue
+		}
+		if i < len(template.TextRegions) {
+			captions[i] = strings.ToUpper(strings.ReplaceAll(template.TextRegions[i].Name, "-", " "))
+			continue
+		}
+		switch i {
+		case 0:
+			captions[i] = "TOP TEXT"
+		case 1:
+			captions[i] = "BOTTOM TEXT"
+		default:
+			captions[i] = fmt.Sprintf("TEXT %d", i+1)
+		}
+	}
+
+	return captions[0], captions[1], captions[2:]
+}
+
+// thumbnail scales img down so its longest side is at most maxSide; smaller images are kept as is
+func thumbnail(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return img
+	}
+
+	if width >= height {
+		height = height * maxSide / width
+		width = maxSide
+	} else {
+		width = width * maxSide / height
+		height = maxSide
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, max(width, 1), max(height, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
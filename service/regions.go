@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// Text alignments supported by text regions. Start and end follow the direction of
+// each paragraph: start is left for left-to-right text and right for right-to-left text.
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+	AlignStart  = "start"
+	AlignEnd    = "end"
+)
+
+// TextRegion describes the box a caption is drawn into, in template image pixels
+type TextRegion struct {
+	Name     string  `json:"name"`
+	X        int     `json:"x"`
+	Y        int     `json:"y"`
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Align    string  `json:"align,omitempty"`
+	MaxLines int     `json:"max_lines,omitempty"`
+	Rotation float64 `json:"rotation,omitempty"` // Degrees clockwise around the region center
+}
+
+// Rect returns the region as an image rectangle
+func (r TextRegion) Rect() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+// validateRegions checks the text regions declared for a template
+func validateRegions(regions []TextRegion) []string {
+	var problems []string
+	names := make(map[string]bool)
+
+	for i, region := range regions {
+		ref := fmt.Sprintf("text region %d", i)
+		if region.Name != "" {
+			ref = fmt.Sprintf("text region '%s'", region.Name)
+		}
+
+		if region.Name == "" {
+			problems = append(problems, fmt.Sprintf("%s: name is required", ref))
+		} else if names[region.Name] {
+			problems = append(problems, fmt.Sprintf("%s: duplicate name", ref))
+		}
+		names[region.Name] = true
+
+		if region.X < 0 || region.Y < 0 {
+			problems = append(problems, fmt.Sprintf("%s: x and y must not be negative", ref))
+		}
+		if region.Width <= 0 || region.Height <= 0 {
+			problems = append(problems, fmt.Sprintf("%s: width and height must be positive", ref))
+		}
+
+		switch region.Align {
+		case "", AlignLeft, AlignCenter, AlignRight, AlignStart, AlignEnd:
+		default:
+			problems = append(problems, fmt.Sprintf("%s: align must be one of left, center, right, start or end", ref))
+		}
+
+		if region.MaxLines < 0 {
+			problems = append(problems, fmt.Sprintf("%s: max_lines must not be negative", ref))
+		}
+		if region.Rotation < -180 || region.Rotation > 180 {
+			problems = append(problems, fmt.Sprintf("%s: rotation must be between -180 and 180 degrees", ref))
+		}
+	}
+
+	return problems
+}
+
+// drawTextInRegion renders a caption inside a template text region, fitting it to the
+// region. The caption style already includes the region's alignment.
+func (s *MemeService) drawTextInRegion(dst draw.Image, caption styledCaption, region TextRegion, fontSize float64) error {
+	// Keep at least one line inside the region height
+	if maxSize := float64(region.Height) / s.Config.LineSpacing; fontSize > maxSize {
+		fontSize = maxSize
+	}
+
+	// Lay the caption out within the region, keeping clear of its edges
+	fit, err := s.fitCaption(caption, captionBox{
+		name:     fmt.Sprintf("text region '%s'", region.Name),
+		width:    region.Width,
+		height:   region.Height,
+		maxLines: region.MaxLines,
+		size:     fontSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Render onto a transparent layer the size of the region so text is clipped to it,
+	// centering the lines vertically
+	layer := image.NewRGBA(image.Rect(0, 0, region.Width, region.Height))
+	drawTextWithStroke(layer, fit.face, fit.layout, image.Pt(fit.padding, (region.Height-fit.layout.Height)/2), caption.style, fit.size)
+
+	if region.Rotation == 0 {
+		draw.Draw(dst, region.Rect(), layer, image.Point{}, draw.Over)
+		return nil
+	}
+
+	// Rotate the layer around its center and place that center on the region center
+	theta := region.Rotation * math.Pi / 180
+	sin, cos := math.Sin(theta), math.Cos(theta)
+	srcCX, srcCY := float64(region.Width)/2, float64(region.Height)/2
+	dstCX := float64(region.X) + srcCX
+	dstCY := float64(region.Y) + srcCY
+
+	transform := f64.Aff3{
+		cos, -sin, dstCX - (cos*srcCX - sin*srcCY),
+		sin, cos, dstCY - (sin*srcCX + cos*srcCY),
+	}
+	draw.BiLinear.Transform(dst, transform, layer, layer.Bounds(), draw.Over, nil)
+	return nil
+}
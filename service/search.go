@@ -0,0 +1,226 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sort orders supported by SearchTemplates
+const (
+	SortByName  = "name"
+	SortByID    = "id"
+	SortByAdded = "added" // Newest first
+
+	// Usage orders, most used first; see UsageStats
+	SortByTrending = "trending" // Most generated in the last day
+	SortByPopular  = "popular"  // Most generated of all time
+)
+
+// Page size limits for SearchTemplates
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ErrInvalidQuery is returned for malformed search parameters
+var ErrInvalidQuery = errors.New("invalid template query")
+
+// TemplateQuery selects, orders and pages through the template catalog
+type TemplateQuery struct {
+	Category  string // Matches any of the template's categories, ignoring case
+	Tag       string // Matches any of the template's tags, ignoring case
+	Label     string // Matches any category or tag, like the ListTemplates category filter
+	Text      string // Case-insensitive match against names, ids, aliases, tags and keywords
+	SortBy    string // SortByName (default), SortByID, SortByAdded, SortByTrending or SortByPopular
+	PageSize  int    // Defaults to DefaultPageSize, capped at MaxPageSize
+	PageToken string // NextPageToken from the previous page
+}
+
+// TemplatePage is one page of search results
+type TemplatePage struct {
+	Templates     []*TemplateInfo
+	NextPageToken string // Empty on the last page
+	TotalSize     int    // Number of templates matching the query across all pages
+}
+
+// SearchTemplates returns the templates matching the query in a stable order, one page at a time
+func (s *MemeService) SearchTemplates(query *TemplateQuery) (*TemplatePage, error) {
+	sortBy := query.SortBy
+	if sortBy == "" {
+		sortBy = SortByName
+	}
+	less, supported := s.templateOrder(sortBy)
+	if !supported {
+		return nil, fmt.Errorf("%w: unknown sort order '%s'", ErrInvalidQuery, query.SortBy)
+	}
+
+	pageSize := query.PageSize
+	if pageSize < 0 {
+		return nil, fmt.Errorf("%w: page size must not be negative", ErrInvalidQuery)
+	}
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	offset, err := decodePageToken(query.PageToken, query)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+
+	var matches []*TemplateInfo
+	for id, info := range s.TemplateCatalog() {
+		if query.Category != "" && !info.HasCategory(query.Category) {
+			continue
+		}
+		if query.Tag != "" && !info.HasTag(query.Tag) {
+			continue
+		}
+		if query.Label != "" && !info.HasLabel(query.Label) {
+			continue
+		}
+
+		result := *info
+		result.ID = id
+		if text != "" && !result.matchesText(text) {
+			continue
+		}
+		matches = append(matches, &result)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return less(matches[i], matches[j])
+	})
+
+	page := &TemplatePage{TotalSize: len(matches)}
+	if offset >= len(matches) {
+		return page, nil
+	}
+
+	end := offset + pageSize
+	if end < len(matches) {
+		page.NextPageToken = encodePageToken(end, query)
+	} else {
+		end = len(matches)
+	}
+	page.Templates = matches[offset:end]
+
+	return page, nil
+}
+
+// templateOrders holds the comparison for each sort order. Ties are broken by id
+// so that pages never overlap or skip entries.
+var templateOrders = map[string]func(a, b *TemplateInfo) bool{
+	SortByName: func(a, b *TemplateInfo) bool {
+		nameA, nameB := strings.ToLower(a.Name), strings.ToLower(b.Name)
+		if nameA != nameB {
+			return nameA < nameB
+		}
+		return a.ID < b.ID
+	},
+	SortByID: func(a, b *TemplateInfo) bool {
+		return a.ID < b.ID
+	},
+	SortByAdded: func(a, b *TemplateInfo) bool {
+		if !a.AddedAt.Equal(b.AddedAt) {
+			return a.AddedAt.After(b.AddedAt)
+		}
+		return a.ID < b.ID
+	},
+}
+
+// usageOrders ranks templates by a primary and a secondary usage count, highest first
+var usageOrders = map[string]func(usage TemplateUsage) (int64, int64){
+	SortByTrending: func(usage TemplateUsage) (int64, int64) {
+		return usage.LastDay, usage.LastWeek
+	},
+	SortByPopular: func(usage TemplateUsage) (int64, int64) {
+		return usage.Total, usage.LastMonth
+	},
+}
+
+// templateOrder returns the comparison for a sort order. Usage orders rank by a
+// snapshot of the statistics taken now, so they may shift between pages.
+func (s *MemeService) templateOrder(sortBy string) (func(a, b *TemplateInfo) bool, bool) {
+	if less, supported := templateOrders[sortBy]; supported {
+		return less, true
+	}
+
+	score, supported := usageOrders[sortBy]
+	if !supported {
+		return nil, false
+	}
+
+	usage := s.usageSnapshot(time.Now())
+	return func(a, b *TemplateInfo) bool {
+		primaryA, secondaryA := score(usage[a.ID])
+		primaryB, secondaryB := score(usage[b.ID])
+		if primaryA != primaryB {
+			return primaryA > primaryB
+		}
+		if secondaryA != secondaryB {
+			return secondaryA > secondaryB
+		}
+		return a.ID < b.ID
+	}, true
+}
+
+// matchesText reports whether the lowercase text occurs in the name, id, aliases, tags or keywords of the template
+func (t *TemplateInfo) matchesText(text string) bool {
+	if strings.Contains(strings.ToLower(t.Name), text) || strings.Contains(t.ID, text) {
+		return true
+	}
+	for _, terms := range [][]string{t.Aliases, t.Tags, t.Keywords} {
+		for _, term := range terms {
+			if strings.Contains(strings.ToLower(term), text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// encodePageToken builds an opaque token for the page starting at offset. The token
+// is bound to the query so it cannot be replayed against a different search.
+func encodePageToken(offset int, query *TemplateQuery) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", offset, queryFingerprint(query))))
+}
+
+// decodePageToken returns the offset stored in a page token, or zero for an empty token
+func decodePageToken(token string, query *TemplateQuery) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed page token", ErrInvalidQuery)
+	}
+
+	offsetPart, fingerprint, found := strings.Cut(string(raw), ":")
+	offset, err := strconv.Atoi(offsetPart)
+	if !found || err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w: malformed page token", ErrInvalidQuery)
+	}
+	if fingerprint != queryFingerprint(query) {
+		return 0, fmt.Errorf("%w: page token does not match the query", ErrInvalidQuery)
+	}
+
+	return offset, nil
+}
+
+// queryFingerprint summarizes the parts of a query that determine the result order
+func queryFingerprint(query *TemplateQuery) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{query.Category, query.Tag, query.Label, query.Text, query.SortBy}, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(sum[:6])
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// usageRetentionHours is how long hourly usage buckets are kept; it covers the longest window
+const usageRetentionHours = 30 * 24
+
+// TemplateUsage counts the memes generated from a template. The rolling windows
+// have hourly resolution: LastHour covers the current clock hour.
+type TemplateUsage struct {
+	ID        string `json:"id"`
+	LastHour  int64  `json:"last_hour"`
+	LastDay   int64  `json:"last_day"`
+	LastWeek  int64  `json:"last_week"`
+	LastMonth int64  `json:"last_month"`
+	Total     int64  `json:"total"`
+}
+
+// usageCounter holds the all-time count of a template and its recent hourly buckets,
+// keyed by hours since the Unix epoch
+type usageCounter struct {
+	Total int64           `json:"total"`
+	Hours map[int64]int64 `json:"hours,omitempty"`
+}
+
+// usageFile is the on-disk form of the usage statistics
+type usageFile struct {
+	Templates map[string]*usageCounter `json:"templates"`
+}
+
+// RecordUsage counts one meme generated from the template with the given id at the given time
+func (s *MemeService) RecordUsage(id string, at time.Time) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	if s.usage == nil {
+		s.usage = make(map[string]*usageCounter)
+	}
+	counter, exists := s.usage[id]
+	if !exists {
+		counter = &usageCounter{}
+		s.usage[id] = counter
+	}
+	if counter.Hours == nil {
+		counter.Hours = make(map[int64]int64)
+	}
+
+	hour := at.Unix() / 3600
+	counter.Total++
+	counter.Hours[hour]++
+
+	// Drop buckets that have fallen out of every window
+	for bucket := range counter.Hours {
+		if hour-bucket >= usageRetentionHours {
+			delete(counter.Hours, bucket)
+		}
+	}
+
+	s.usageDirty = true
+}
+
+// UsageStats returns the usage of every template in the catalog, most used first
+func (s *MemeService) UsageStats() []TemplateUsage {
+	usage := s.usageSnapshot(time.Now())
+
+	catalog := s.TemplateCatalog()
+	stats := make([]TemplateUsage, 0, len(catalog))
+	for id := range catalog {
+		entry := usage[id]
+		entry.ID = id
+		stats = append(stats, entry)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Total != stats[j].Total {
+			return stats[i].Total > stats[j].Total
+		}
+		return stats[i].ID < stats[j].ID
+	})
+
+	return stats
+}
+
+// usageSnapshot computes the rolling window counts of every recorded template as of now
+func (s *MemeService) usageSnapshot(now time.Time) map[string]TemplateUsage {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	current := now.Unix() / 3600
+	snapshot := make(map[string]TemplateUsage, len(s.usage))
+	for id, counter := range s.usage {
+		usage := TemplateUsage{ID: id, Total: counter.Total}
+		for hour, count := range counter.Hours {
+			age := current - hour
+			if age < 1 {
+				usage.LastHour += count
+			}
+			if age < 24 {
+				usage.LastDay += count
+			}
+			if age < 7*24 {
+				usage.LastWeek += count
+			}
+			if age < usageRetentionHours {
+				usage.LastMonth += count
+			}
+		}
+		snapshot[id] = usage
+	}
+
+	return snapshot
+}
+
+// LoadUsageStats restores the usage statistics saved in the configured stats file.
+// A missing file is not an error; the statistics simply start empty.
+func (s *MemeService) LoadUsageStats() error {
+	if s.Config.StatsFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.Config.StatsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read usage stats: %v", err)
+	}
+
+	var file usageFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse usage stats %s: %v", s.Config.StatsFile, err)
+	}
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	s.usage = file.Templates
+	s.usageDirty = false
+	return nil
+}
+
+// SaveUsageStats writes the usage statistics to the configured stats file
+// if they changed since the last save
+func (s *MemeService) SaveUsageStats() error {
+	if s.Config.StatsFile == "" {
+		return nil
+	}
+
+	s.statsMu.Lock()
+	if !s.usageDirty {
+		s.statsMu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(usageFile{Templates: s.usage})
+	s.usageDirty = false
+	s.statsMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode usage stats: %v", err)
+	}
+
+	if err := writeFileAtomic(s.Config.StatsFile, data); err != nil {
+		// Try again on the next save
+		s.statsMu.Lock()
+		s.usageDirty = true
+		s.statsMu.Unlock()
+		return fmt.Errorf("failed to write usage stats: %v", err)
+	}
+
+	return nil
+}
+
+// PersistUsageStats saves the usage statistics every interval until ctx is canceled
+func (s *MemeService) PersistUsageStats(ctx context.Context, interval time.Duration) {
+	if s.Config.StatsFile == "" || interval <= 0 {
+		log.Println("Usage stats persistence disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.SaveUsageStats(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// writeFileAtomic replaces path with data via a temporary file in the same directory,
+// creating the directory if needed
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
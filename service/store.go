@@ -0,0 +1,419 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png" // Register the PNG decoder for uploaded templates
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Errors returned by the template management operations
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateExists   = errors.New("template already exists")
+	ErrInvalidTemplate  = errors.New("invalid template")
+)
+
+// templateExtensions maps decoded image formats to the extension used on disk
+var templateExtensions = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+}
+
+// TemplateUpdate holds the metadata changes for a template; nil fields are left unchanged
+type TemplateUpdate struct {
+	Name            *string       `json:"name,omitempty"`
+	Category        *string       `json:"category,omitempty"`
+	Categories      *[]string     `json:"categories,omitempty"`
+	Tags            *[]string     `json:"tags,omitempty"`
+	Aliases         *[]string     `json:"aliases,omitempty"`
+	TextFieldCount  *int32        `json:"text_field_count,omitempty"`
+	Keywords        *[]string     `json:"keywords,omitempty"`
+	TextRegions     *[]TextRegion `json:"text_regions,omitempty"`
+	TextStyle       *TextStyle    `json:"text_style,omitempty"` // Replaces the whole style
+	ExampleCaptions *[]string     `json:"example_captions,omitempty"`
+}
+
+// CreateTemplate validates and stores a new template image with its metadata,
+// then persists the manifest so the template survives restarts
+func (s *MemeService) CreateTemplate(info *TemplateInfo, img io.Reader) (*TemplateInfo, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	created := *info
+	normalizeTemplate(&created)
+	if problems := validateTemplateMetadata(&created); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, problems[0])
+	}
+	if _, exists := s.storedTemplate(created.ID); exists {
+		return nil, fmt.Errorf("%w: '%s'", ErrTemplateExists, created.ID)
+	}
+
+	filename, err := s.storeTemplateImage(created.ID, img)
+	if err != nil {
+		return nil, err
+	}
+	created.Filename = filename
+	created.AddedAt = time.Now().UTC()
+	created.SHA256 = "" // Recorded from the stored image
+
+	if err := s.commitTemplates(func(templates map[string]*TemplateInfo) {
+		templates[created.ID] = &created
+	}); err != nil {
+		os.Remove(filepath.Join(s.Config.TemplateDir, filename))
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// ReplaceTemplateImage swaps the image of an existing template, keeping its metadata.
+// This also restores a quarantined template.
+func (s *MemeService) ReplaceTemplateImage(id string, img io.Reader) (*TemplateInfo, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	existing, exists := s.storedTemplate(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: '%s'", ErrTemplateNotFound, id)
+	}
+
+	// Keep a copy of the current image until the manifest is written, so a failed commit
+	// leaves the template as it was
+	backup, err := s.backupTemplateImage(existing.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if backup != "" {
+		defer os.Remove(backup)
+	}
+
+	filename, err := s.storeTemplateImage(id, img)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *existing
+	updated.Filename = filename
+	updated.SHA256 = ""
+	updated.quarantine = ""
+	if err := s.commitTemplates(func(templates map[string]*TemplateInfo) {
+		templates[id] = &updated
+	}); err != nil {
+		if filename != existing.Filename {
+			os.Remove(filepath.Join(s.Config.TemplateDir, filename))
+		} else if backup != "" {
+			os.Rename(backup, filepath.Join(s.Config.TemplateDir, filename))
+		}
+		return nil, err
+	}
+
+	// A JPEG replaced by a PNG leaves the old file behind
+	if existing.Filename != filename {
+		s.removeUnreferencedImage(existing.Filename)
+	}
+
+	return &updated, nil
+}
+
+// UpdateTemplate changes the metadata of an existing template
+func (s *MemeService) UpdateTemplate(id string, update *TemplateUpdate) (*TemplateInfo, error) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	existing, exists := s.Template(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: '%s'", ErrTemplateNotFound, id)
+	}
+
+	updated := *existing
+	if update.Name != nil {
+		updated.Name = *update.Name
+	}
+	if update.Category != nil {
+		updated.Category = *update.Category
+	}
+	if update.Categories != nil {
+		updated.Categories = *update.Categories
+	}
+	if update.Tags != nil {
+		updated.Tags = *update.Tags
+	}
+	if update.Aliases != nil {
+		updated.Aliases = *update.Aliases
+	}
+	normalizeTemplate(&updated)
+	if update.TextFieldCount != nil {
+		updated.TextFieldCount = *update.TextFieldCount
+	}
+	if update.Keywords != nil {
+		updated.Keywords = *update.Keywords
+	}
+	if update.TextRegions != nil {
+		updated.TextRegions = *update.TextRegions
+	}
+	if update.TextStyle != nil {
+		updated.TextStyle = update.TextStyle
+	}
+	if update.ExampleCaptions != nil {
+		updated.ExampleCaptions = *update.ExampleCaptions
+	}
+
+	if problems := validateTemplateMetadata(&updated); len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, problems[0])
+	}
+
+	if err := s.commitTemplates(func(templates map[string]*TemplateInfo) {
+		templates[id] = &updated
+	}); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeleteTemplate removes a template, including a quarantined one, and deletes its image
+func (s *MemeService) DeleteTemplate(id string) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+
+	existing, exists := s.storedTemplate(id)
+	if !exists {
+		return fmt.Errorf("%w: '%s'", ErrTemplateNotFound, id)
+	}
+
+	if err := s.commitTemplates(func(templates map[string]*TemplateInfo) {
+		delete(templates, id)
+	}); err != nil {
+		return err
+	}
+
+	s.removeUnreferencedImage(existing.Filename)
+	return nil
+}
+
+// storeTemplateImage streams an uploaded image into the template directory after
+// checking its size, format and dimensions, and returns the stored filename
+func (s *MemeService) storeTemplateImage(id string, img io.Reader) (string, error) {
+	tmp, err := os.CreateTemp(s.Config.TemplateDir, ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Read one byte past the limit so oversized uploads can be detected
+	written, err := io.Copy(tmp, io.LimitReader(img, s.Config.MaxTemplateBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read template image: %v", err)
+	}
+	if written > s.Config.MaxTemplateBytes {
+		return "", fmt.Errorf("%w: image exceeds %d bytes", ErrInvalidTemplate, s.Config.MaxTemplateBytes)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read template image: %v", err)
+	}
+
+	// Check the header first so oversized images are rejected before decoding them
+	imgConfig, format, err := image.DecodeConfig(tmp)
+	if err != nil {
+		return "", fmt.Errorf("%w: image could not be decoded: %v", ErrInvalidTemplate, err)
+	}
+
+	ext, supported := templateExtensions[format]
+	if !supported {
+		return "", fmt.Errorf("%w: unsupported image format %s", ErrInvalidTemplate, format)
+	}
+
+	if err := checkTemplateDimensions(imgConfig.Width, imgConfig.Height, s.Config.MaxTemplateDimension); err != nil {
+		return "", err
+	}
+
+	// Decode the whole image so truncated uploads are rejected, not just bad headers
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read template image: %v", err)
+	}
+	if _, _, err := image.Decode(tmp); err != nil {
+		return "", fmt.Errorf("%w: image could not be decoded: %v", ErrInvalidTemplate, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write template image: %v", err)
+	}
+
+	// Never overwrite an image that belongs to a different template
+	filename := id + ext
+	for otherID, info := range s.manifestTemplates() {
+		if otherID != id && info.Filename == filename {
+			return "", fmt.Errorf("%w: image %s is used by template '%s'", ErrTemplateExists, filename, otherID)
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(s.Config.TemplateDir, filename)); err != nil {
+		return "", fmt.Errorf("failed to store template image: %v", err)
+	}
+
+	return filename, nil
+}
+
+// backupTemplateImage copies a template image to a hidden file in the template directory
+// and returns its path, or an empty path when the image does not exist
+func (s *MemeService) backupTemplateImage(filename string) (string, error) {
+	src, err := os.Open(filepath.Join(s.Config.TemplateDir, filename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to back up template image: %v", err)
+	}
+	defer src.Close()
+
+	backup, err := os.CreateTemp(s.Config.TemplateDir, ".backup-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to back up template image: %v", err)
+	}
+	if _, err := io.Copy(backup, src); err != nil {
+		backup.Close()
+		os.Remove(backup.Name())
+		return "", fmt.Errorf("failed to back up template image: %v", err)
+	}
+	if err := backup.Close(); err != nil {
+		os.Remove(backup.Name())
+		return "", fmt.Errorf("failed to back up template image: %v", err)
+	}
+
+	return backup.Name(), nil
+}
+
+// checkTemplateDimensions rejects images that are too small to caption or too large to render
+func checkTemplateDimensions(width, height, maxDimension int) error {
+	const minDimension = 64
+
+	if width < minDimension || height < minDimension {
+		return fmt.Errorf("%w: image is %dx%d, minimum is %dx%d", ErrInvalidTemplate, width, height, minDimension, minDimension)
+	}
+	if width > maxDimension || height > maxDimension {
+		return fmt.Errorf("%w: image is %dx%d, maximum is %dx%d", ErrInvalidTemplate, width, height, maxDimension, maxDimension)
+	}
+
+	return nil
+}
+
+// commitTemplates applies change to a copy of the stored templates, quarantined ones
+// included, writes the manifest and then publishes the new catalog
+func (s *MemeService) commitTemplates(change func(templates map[string]*TemplateInfo)) error {
+	current := s.manifestTemplates()
+	templates := s.manifestTemplates()
+	change(templates)
+
+	// Changed entries need a fresh version and checksum; unchanged ones keep theirs
+	images := os.DirFS(s.Config.TemplateDir)
+	for id, info := range templates {
+		if current[id] == info {
+			continue
+		}
+		version, checksum, err := computeTemplateVersion(images, info)
+		if err != nil {
+			return fmt.Errorf("failed to read template image: %v", err)
+		}
+		info.Version = version
+		verifyChecksum(info, checksum)
+		if info.Quarantined() {
+			return fmt.Errorf("template '%s' failed verification: %s", id, info.quarantine)
+		}
+	}
+
+	if conflicts := checkAliasConflicts(templates); len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrTemplateExists, conflicts[0].String())
+	}
+
+	// The manifest records every checksum from now on. Published entries are shared, so
+	// they are copied rather than modified.
+	for id, info := range templates {
+		if info.unverified {
+			verified := *info
+			verified.unverified = false
+			templates[id] = &verified
+		}
+	}
+
+	if err := writeManifest(s.Config.TemplateDir, templates); err != nil {
+		return err
+	}
+
+	s.publishCatalog(templates)
+	return nil
+}
+
+// removeUnreferencedImage deletes an image file unless another template still uses it
+func (s *MemeService) removeUnreferencedImage(filename string) {
+	for _, info := range s.manifestTemplates() {
+		if info.Filename == filename {
+			return
+		}
+	}
+	os.Remove(filepath.Join(s.Config.TemplateDir, filename))
+}
+
+// writeManifest atomically replaces the manifest in dir with the given templates, sorted by id
+func writeManifest(dir string, templates map[string]*TemplateInfo) error {
+	manifest := TemplateManifest{Templates: make([]*TemplateInfo, 0, len(templates))}
+	for id, info := range templates {
+		entry := *info
+		entry.ID = id
+		entry.Version = "" // Derived from the content on load
+		manifest.Templates = append(manifest.Templates, &entry)
+	}
+	sort.Slice(manifest.Templates, func(i, j int) bool {
+		return manifest.Templates[i].ID < manifest.Templates[j].ID
+	})
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode template manifest: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".manifest-*")
+	if err != nil {
+		return fmt.Errorf("failed to write template manifest: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write template manifest: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write template manifest: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, ManifestFilename)); err != nil {
+		return fmt.Errorf("failed to write template manifest: %v", err)
+	}
+
+	return nil
+}
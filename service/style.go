@@ -0,0 +1,290 @@
+package service
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+)
+
+// Text casings supported by text styles
+const (
+	CaseAsTyped = "as-typed"
+	CaseUpper   = "upper"
+)
+
+// MaxStrokeWidth is the widest outline a text style may declare, in pixels
+const MaxStrokeWidth = 20
+
+// MaxEffectRadius is the largest blur radius, glow radius and shadow offset a text style
+// may declare, in pixels
+const MaxEffectRadius = 50
+
+// TextStyle is the default look of a template's captions. Empty fields keep the
+// classic meme style: white text with a black outline, as typed and centered.
+type TextStyle struct {
+	Font        string      `json:"font,omitempty"`         // Font in FONT_DIR by name, with or without .ttf; empty uses FONT_FILE itself
+	Color       string      `json:"color,omitempty"`        // Fill colour as #rgb, #rrggbb or #rrggbbaa
+	StrokeColor string      `json:"stroke_color,omitempty"` // Outline colour, same format as Color
+	StrokeWidth *int        `json:"stroke_width,omitempty"` // Outline width in pixels; 0 disables it, unset scales with the font size
+	Case        string      `json:"case,omitempty"`         // CaseAsTyped (default) or CaseUpper
+	Align       string      `json:"align,omitempty"`        // Default alignment; a text region's own align takes precedence
+	Shadow      *TextShadow `json:"shadow,omitempty"`       // Drop shadow under the outline and text
+	Glow        *TextGlow   `json:"glow,omitempty"`         // Outer glow under the outline and text
+}
+
+// TextShadow is a soft drop shadow cast by the text and its outline
+type TextShadow struct {
+	OffsetX int      `json:"offset_x,omitempty"` // Pixels to the right; negative moves the shadow left
+	OffsetY int      `json:"offset_y,omitempty"` // Pixels down; negative moves the shadow up
+	Blur    float64  `json:"blur,omitempty"`     // Gaussian blur radius in pixels; 0 casts a hard shadow
+	Color   string   `json:"color,omitempty"`    // Same format as TextStyle.Color; black by default
+	Opacity *float64 `json:"opacity,omitempty"`  // From 0 to 1, applied on top of the colour's alpha; 1 by default
+}
+
+// TextGlow is a soft halo around the text and its outline
+type TextGlow struct {
+	Radius  float64  `json:"radius"`            // How far the glow reaches, in pixels
+	Color   string   `json:"color,omitempty"`   // Same format as TextStyle.Color; white by default
+	Opacity *float64 `json:"opacity,omitempty"` // From 0 to 1, applied on top of the colour's alpha; 1 by default
+}
+
+// renderStyle is a TextStyle resolved for drawing
+type renderStyle struct {
+	fill        color.Color
+	stroke      color.Color
+	strokeWidth int // Negative scales the outline with the font size
+	upper       bool
+	align       string
+	shadow      *textEffect
+	glow        *textEffect
+}
+
+// textEffect is a shadow or glow resolved for drawing: the text silhouette grown by
+// spread, blurred by blur and drawn at offset in color
+type textEffect struct {
+	offset image.Point
+	spread int
+	blur   float64
+	color  color.Color
+}
+
+// defaultRenderStyle is the classic white-on-black meme style
+var defaultRenderStyle = renderStyle{
+	fill:        color.White,
+	stroke:      color.Black,
+	strokeWidth: -1,
+	align:       AlignCenter,
+}
+
+// renderStyle resolves the text style of a template, filling in the defaults
+func (t *TemplateInfo) renderStyle() renderStyle {
+	return defaultRenderStyle.apply(t.TextStyle)
+}
+
+// apply returns the style with the fields set in style replaced. Styles are validated
+// before use, so colours that fail to parse keep their current value.
+func (r renderStyle) apply(style *TextStyle) renderStyle {
+	if style == nil {
+		return r
+	}
+
+	if fill, err := parseColor(style.Color); err == nil {
+		r.fill = fill
+	}
+	if stroke, err := parseColor(style.StrokeColor); err == nil {
+		r.stroke = stroke
+	}
+	if style.StrokeWidth != nil {
+		r.strokeWidth = *style.StrokeWidth
+	}
+	if style.Case != "" {
+		r.upper = style.Case == CaseUpper
+	}
+	if style.Align != "" {
+		r.align = style.Align
+	}
+	if shadow := style.Shadow; shadow != nil {
+		r.shadow = &textEffect{
+			offset: image.Pt(shadow.OffsetX, shadow.OffsetY),
+			blur:   shadow.Blur,
+			color:  effectColor(shadow.Color, color.NRGBA{A: 0xff}, shadow.Opacity),
+		}
+	}
+	if glow := style.Glow; glow != nil {
+		// Half of the radius grows the silhouette and half blurs it, so the glow is
+		// strong next to the text and fades out over the radius
+		r.glow = &textEffect{
+			spread: int(glow.Radius / 2),
+			blur:   glow.Radius / 2,
+			color:  effectColor(glow.Color, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, glow.Opacity),
+		}
+	}
+
+	return r
+}
+
+// effectColor returns the colour of a shadow or glow: value, or fallback when it is
+// empty, with opacity applied to its alpha
+func effectColor(value string, fallback color.NRGBA, opacity *float64) color.NRGBA {
+	c, err := parseColor(value)
+	if err != nil {
+		c = fallback
+	}
+	if opacity != nil {
+		c.A = uint8(float64(c.A)**opacity + 0.5)
+	}
+	return c
+}
+
+// templateFont returns the font declared by the template's text style, falling back
+// to the configured font when the template has none or it cannot be loaded
+func (s *MemeService) templateFont(template *TemplateInfo) (*truetype.Font, error) {
+	if template.TextStyle == nil || template.TextStyle.Font == "" {
+		return s.loadFont()
+	}
+
+	f, err := s.loadNamedFont(template.TextStyle.Font)
+	if err != nil {
+		log.Printf("Warning: failed to load font %s for template %s, using the default font: %v", template.TextStyle.Font, template.ID, err)
+		return s.loadFont()
+	}
+	return f, nil
+}
+
+// withAlign returns the style with its alignment replaced, unless align is empty
+func (r renderStyle) withAlign(align string) renderStyle {
+	if align != "" {
+		r.align = align
+	}
+	return r
+}
+
+// transform applies the casing of the style to text
+func (r renderStyle) transform(text string) string {
+	if r.upper {
+		return strings.ToUpper(text)
+	}
+	return text
+}
+
+// outlineWidth returns the outline width in pixels for text drawn at fontSize
+func (r renderStyle) outlineWidth(fontSize float64) int {
+	if r.strokeWidth >= 0 {
+		return r.strokeWidth
+	}
+
+	width := int(fontSize / 6)
+	if width < 1 {
+		width = 1
+	}
+	return width
+}
+
+// parseColor parses a hex colour in #rgb, #rrggbb or #rrggbbaa form
+func parseColor(value string) (color.NRGBA, error) {
+	hex, found := strings.CutPrefix(value, "#")
+	if !found {
+		return color.NRGBA{}, fmt.Errorf("colour %q must start with #", value)
+	}
+
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("colour %q must be #rgb, #rrggbb or #rrggbbaa", value)
+	}
+
+	rgba, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("colour %q is not hexadecimal", value)
+	}
+
+	return color.NRGBA{R: uint8(rgba >> 24), G: uint8(rgba >> 16), B: uint8(rgba >> 8), A: uint8(rgba)}, nil
+}
+
+// validateTextStyle checks a text style, prefixing problems with ref
+func validateTextStyle(ref string, style *TextStyle) []string {
+	if style == nil {
+		return nil
+	}
+
+	var problems []string
+
+	if style.Font != "" && filepath.Base(style.Font) != style.Font {
+		problems = append(problems, fmt.Sprintf("%s: font %s must not contain a directory", ref, style.Font))
+	}
+
+	for _, value := range []string{style.Color, style.StrokeColor} {
+		if value == "" {
+			continue
+		}
+		if _, err := parseColor(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", ref, err))
+		}
+	}
+
+	if style.StrokeWidth != nil && (*style.StrokeWidth < 0 || *style.StrokeWidth > MaxStrokeWidth) {
+		problems = append(problems, fmt.Sprintf("%s: stroke_width must be between 0 and %d", ref, MaxStrokeWidth))
+	}
+
+	switch style.Case {
+	case "", CaseAsTyped, CaseUpper:
+	default:
+		problems = append(problems, ref+": case must be upper or as-typed")
+	}
+
+	switch style.Align {
+	case "", AlignLeft, AlignCenter, AlignRight, AlignStart, AlignEnd:
+	default:
+		problems = append(problems, ref+": align must be one of left, center, right, start or end")
+	}
+
+	if shadow := style.Shadow; shadow != nil {
+		if shadow.Blur < 0 || shadow.Blur > MaxEffectRadius {
+			problems = append(problems, fmt.Sprintf("%s: shadow.blur must be between 0 and %d", ref, MaxEffectRadius))
+		}
+		if abs(shadow.OffsetX) > MaxEffectRadius || abs(shadow.OffsetY) > MaxEffectRadius {
+			problems = append(problems, fmt.Sprintf("%s: shadow offsets must be between -%d and %d", ref, MaxEffectRadius, MaxEffectRadius))
+		}
+		problems = append(problems, validateEffectColor(ref, "shadow", shadow.Color, shadow.Opacity)...)
+	}
+	if glow := style.Glow; glow != nil {
+		if glow.Radius <= 0 || glow.Radius > MaxEffectRadius {
+			problems = append(problems, fmt.Sprintf("%s: glow.radius must be greater than 0 and at most %d", ref, MaxEffectRadius))
+		}
+		problems = append(problems, validateEffectColor(ref, "glow", glow.Color, glow.Opacity)...)
+	}
+
+	return problems
+}
+
+// validateEffectColor checks the colour and opacity of the named effect, prefixing problems with ref
+func validateEffectColor(ref, effect, value string, opacity *float64) []string {
+	var problems []string
+	if value != "" {
+		if _, err := parseColor(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s %v", ref, effect, err))
+		}
+	}
+	if opacity != nil && (*opacity < 0 || *opacity > 1) {
+		problems = append(problems, fmt.Sprintf("%s: %s.opacity must be between 0 and 1", ref, effect))
+	}
+	return problems
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
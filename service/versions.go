@@ -0,0 +1,244 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// VersionsDirname is the directory inside the template directory that keeps every
+// template version the service has published, so pinned renders stay reproducible
+const VersionsDirname = ".versions"
+
+// versionFilename holds the metadata of an archived version next to its image
+const versionFilename = "template.json"
+
+// versionPattern matches the content hashes produced by computeTemplateVersion
+var versionPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// ErrVersionNotFound is returned when a pinned template version does not exist
+var ErrVersionNotFound = errors.New("template version not found")
+
+// TemplateVersion describes one archived version of a template
+type TemplateVersion struct {
+	Version    string    `json:"version"`
+	ArchivedAt time.Time `json:"archived_at"`
+	Current    bool      `json:"current"`
+}
+
+// archivedVersion is the on-disk form of an archived version
+type archivedVersion struct {
+	Template   *TemplateInfo `json:"template"`
+	ArchivedAt time.Time     `json:"archived_at"`
+}
+
+// computeTemplateVersion hashes the template image together with the metadata that
+// affects rendering, so any change to how the template renders produces a new version.
+// It also returns the SHA-256 of the image alone, which the manifest records as its checksum.
+func computeTemplateVersion(fsys fs.FS, info *TemplateInfo) (string, string, error) {
+	file, err := fsys.Open(info.Filename)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	imageHash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(hash, imageHash), file); err != nil {
+		return "", "", err
+	}
+
+	layout, err := json.Marshal(struct {
+		TextFieldCount int32        `json:"text_field_count"`
+		TextRegions    []TextRegion `json:"text_regions"`
+		TextStyle      *TextStyle   `json:"text_style,omitempty"` // Omitted when unset so older versions keep their hash
+	}{info.TextFieldCount, info.TextRegions, info.TextStyle})
+	if err != nil {
+		return "", "", err
+	}
+	hash.Write([]byte{0})
+	hash.Write(layout)
+
+	return hex.EncodeToString(hash.Sum(nil))[:16], hex.EncodeToString(imageHash.Sum(nil)), nil
+}
+
+// archiveTemplateVersions copies the current version of every template into the
+// versions directory unless it is already there. Failures are logged, not returned,
+// since they only affect pinned renders of future replacements.
+func (s *MemeService) archiveTemplateVersions(templates map[string]*TemplateInfo) {
+	for id, info := range templates {
+		// Built-in templates cannot change, so there is nothing to preserve
+		if info.images != nil || info.Version == "" {
+			continue
+		}
+		if err := s.archiveTemplateVersion(id, info); err != nil {
+			log.Printf("Warning: failed to archive version %s of template %s: %v", info.Version, id, err)
+		}
+	}
+}
+
+// archiveTemplateVersion stores one template version in its own directory. The directory
+// is assembled under a temporary name and renamed, so a version is either complete or absent.
+func (s *MemeService) archiveTemplateVersion(id string, info *TemplateInfo) error {
+	versionDir := filepath.Join(s.Config.TemplateDir, VersionsDirname, id, info.Version)
+	if _, err := os.Stat(versionDir); err == nil {
+		return nil
+	}
+
+	parent := filepath.Dir(versionDir)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(parent, ".archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := copyFile(filepath.Join(s.Config.TemplateDir, info.Filename), filepath.Join(tmp, info.Filename)); err != nil {
+		return err
+	}
+
+	snapshot := *info
+	snapshot.ID = id
+	data, err := json.MarshalIndent(archivedVersion{Template: &snapshot, ArchivedAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, versionFilename), append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, versionDir); err != nil {
+		// Another writer may have archived the same version in the meantime
+		if _, statErr := os.Stat(versionDir); statErr == nil {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// templateAtVersion returns the given version of a template: the catalog entry when it
+// is the current version, otherwise the archived copy. Archived versions remain
+// available after the template is replaced or deleted.
+func (s *MemeService) templateAtVersion(id, version string) (*TemplateInfo, error) {
+	if current, exists := s.Template(id); exists && current.Version == version {
+		return current, nil
+	}
+
+	// Both values become path elements, so only accept well-formed ones
+	if !templateIDPattern.MatchString(id) || !versionPattern.MatchString(version) {
+		return nil, fmt.Errorf("%w: '%s' of template '%s'", ErrVersionNotFound, version, id)
+	}
+
+	versionDir := filepath.Join(s.Config.TemplateDir, VersionsDirname, id, version)
+	archived, err := readArchivedVersion(versionDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: '%s' of template '%s'", ErrVersionNotFound, version, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	archived.Template.images = os.DirFS(versionDir)
+	return archived.Template, nil
+}
+
+// TemplateVersions lists the archived versions of a template, newest first
+func (s *MemeService) TemplateVersions(id string) ([]TemplateVersion, error) {
+	current, exists := s.Template(id)
+
+	if !templateIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%w: '%s'", ErrTemplateNotFound, id)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.Config.TemplateDir, VersionsDirname, id))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list template versions: %v", err)
+	}
+
+	var versions []TemplateVersion
+	for _, entry := range entries {
+		if !entry.IsDir() || !versionPattern.MatchString(entry.Name()) {
+			continue
+		}
+		archived, err := readArchivedVersion(filepath.Join(s.Config.TemplateDir, VersionsDirname, id, entry.Name()))
+		if err != nil {
+			log.Printf("Warning: skipping version %s of template %s: %v", entry.Name(), id, err)
+			continue
+		}
+		versions = append(versions, TemplateVersion{
+			Version:    entry.Name(),
+			ArchivedAt: archived.ArchivedAt,
+			Current:    exists && current.Version == entry.Name(),
+		})
+	}
+
+	// Built-in templates are never archived but still have a current version
+	if exists && current.images != nil {
+		versions = append(versions, TemplateVersion{Version: current.Version, Current: true})
+	}
+
+	if len(versions) == 0 && !exists {
+		return nil, fmt.Errorf("%w: '%s'", ErrTemplateNotFound, id)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		if !versions[i].ArchivedAt.Equal(versions[j].ArchivedAt) {
+			return versions[i].ArchivedAt.After(versions[j].ArchivedAt)
+		}
+		return versions[i].Version < versions[j].Version
+	})
+
+	return versions, nil
+}
+
+// readArchivedVersion decodes the metadata of an archived version
+func readArchivedVersion(versionDir string) (*archivedVersion, error) {
+	data, err := os.ReadFile(filepath.Join(versionDir, versionFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	var archived archivedVersion
+	if err := json.Unmarshal(data, &archived); err != nil {
+		return nil, fmt.Errorf("failed to parse archived version: %v", err)
+	}
+	if archived.Template == nil {
+		return nil, fmt.Errorf("archived version has no template")
+	}
+
+	return &archived, nil
+}
+
+// copyFile copies the contents of src to a new file at dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
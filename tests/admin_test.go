@@ -0,0 +1,289 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAdminToken = "secret"
+
+// testImage returns an encoded solid-colour image of the given size
+func testImage(t *testing.T, format string, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 80, B: 40, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	require.NoError(t, err)
+
+	return buf.Bytes()
+}
+
+// setupAdminTest creates a service backed by a temporary template store and an admin handler for it
+func setupAdminTest(t *testing.T) (*service.MemeService, http.Handler) {
+	s := setupCatalogService(t, singleTemplateManifest, "drake.jpg")
+	s.Config.MaxTemplateBytes = 1 << 20
+	s.Config.MaxTemplateDimension = 1024
+
+	return s, handler.NewAdminHandler(s, testAdminToken)
+}
+
+// uploadRequest builds a multipart template upload
+func uploadRequest(t *testing.T, metadata string, img []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	require.NoError(t, writer.WriteField("metadata", metadata))
+	part, err := writer.CreateFormFile("image", "upload")
+	require.NoError(t, err)
+	_, err = part.Write(img)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/templates", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
+// adminRequest builds an authenticated admin request
+func adminRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
+func TestAdmin_CreateTemplate(t *testing.T) {
+	s, h := setupAdminTest(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, uploadRequest(t,
+		`{"id": "success-kid", "name": "Success Kid", "category": "classic", "text_field_count": 2}`,
+		testImage(t, "png", 200, 150)))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created service.TemplateInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "success-kid.png", created.Filename)
+
+	// The new template is served immediately and persisted to the manifest
+	_, exists := s.Template("success-kid")
+	assert.True(t, exists)
+
+	templates, err := service.LoadTemplates(s.Config.TemplateDir)
+	require.NoError(t, err)
+	assert.Contains(t, templates, "success-kid")
+	assert.Contains(t, templates, "drake")
+}
+
+func TestAdmin_CreateTemplate_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		metadata   string
+		image      []byte
+		wantStatus int
+		wantErr    string
+	}{
+		{
+			name:       "Duplicate id",
+			metadata:   `{"id": "drake", "name": "Drake", "text_field_count": 2}`,
+			image:      testImage(t, "jpeg", 200, 150),
+			wantStatus: http.StatusConflict,
+			wantErr:    "template already exists",
+		},
+		{
+			name:       "Invalid metadata",
+			metadata:   `{"id": "Bad Id", "name": "", "text_field_count": 2}`,
+			image:      testImage(t, "jpeg", 200, 150),
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "id must contain only lowercase letters",
+		},
+		{
+			name:       "Oversized metadata",
+			metadata:   `{"id": "new", "name": "` + strings.Repeat("a", 128<<10) + `", "text_field_count": 2}`,
+			image:      testImage(t, "jpeg", 200, 150),
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "invalid metadata",
+		},
+		{
+			name:       "Not an image",
+			metadata:   `{"id": "new", "name": "New", "text_field_count": 2}`,
+			image:      []byte("definitely not an image"),
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "image could not be decoded",
+		},
+		{
+			name:       "Truncated image",
+			metadata:   `{"id": "new", "name": "New", "text_field_count": 2}`,
+			image:      testImage(t, "png", 200, 150)[:200],
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "image could not be decoded",
+		},
+		{
+			name:       "Too small",
+			metadata:   `{"id": "new", "name": "New", "text_field_count": 2}`,
+			image:      testImage(t, "png", 32, 32),
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "minimum is 64x64",
+		},
+		{
+			name:       "Too large",
+			metadata:   `{"id": "new", "name": "New", "text_field_count": 2}`,
+			image:      testImage(t, "png", 2048, 100),
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "maximum is 1024x1024",
+		},
+		{
+			name:       "Too many bytes",
+			metadata:   `{"id": "new", "name": "New", "text_field_count": 2}`,
+			image:      bytes.Repeat([]byte{0xff}, 2<<20),
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "image exceeds 1048576 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, h := setupAdminTest(t)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, uploadRequest(t, tt.metadata, tt.image))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantErr)
+			assert.Len(t, s.TemplateCatalog(), 1)
+
+			// Rejected uploads must not leave files behind
+			entries, err := os.ReadDir(s.Config.TemplateDir)
+			require.NoError(t, err)
+			assert.Len(t, entries, 2)
+		})
+	}
+}
+
+func TestAdmin_UpdateTemplate(t *testing.T) {
+	s, h := setupAdminTest(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodPatch, "/admin/templates/drake", `{"name": "Drake Approves", "text_field_count": 3}`))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	info, _ := s.Template("drake")
+	assert.Equal(t, "Drake Approves", info.Name)
+	assert.Equal(t, int32(3), info.TextFieldCount)
+	assert.Equal(t, "classic", info.Category)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodPatch, "/admin/templates/drake", `{"text_field_count": 0}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodPatch, "/admin/templates/missing", `{"name": "Missing"}`))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAdmin_ReplaceTemplateImage(t *testing.T) {
+	s, h := setupAdminTest(t)
+
+	req := adminRequest(http.MethodPut, "/admin/templates/drake/image", string(testImage(t, "png", 120, 120)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	info, _ := s.Template("drake")
+	assert.Equal(t, "drake.png", info.Filename)
+
+	// The old JPEG is no longer referenced and is removed
+	_, err := os.Stat(filepath.Join(s.Config.TemplateDir, "drake.jpg"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestReplaceTemplateImage_FailedCommitKeepsImage(t *testing.T) {
+	s, _ := setupAdminTest(t)
+	path := filepath.Join(s.Config.TemplateDir, "drake.jpg")
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// A directory in place of the manifest makes writing it fail
+	manifest := filepath.Join(s.Config.TemplateDir, service.ManifestFilename)
+	require.NoError(t, os.Remove(manifest))
+	require.NoError(t, os.MkdirAll(filepath.Join(manifest, "blocked"), 0755))
+
+	_, err = s.ReplaceTemplateImage("drake", bytes.NewReader(testImage(t, "jpeg", 100, 100)))
+	require.Error(t, err)
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	entries, err := os.ReadDir(s.Config.TemplateDir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, strings.HasPrefix(entry.Name(), ".backup-"), entry.Name())
+	}
+}
+
+func TestAdmin_DeleteTemplate(t *testing.T) {
+	s, h := setupAdminTest(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/templates/drake", ""))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	assert.Empty(t, s.TemplateCatalog())
+	_, err := os.Stat(filepath.Join(s.Config.TemplateDir, "drake.jpg"))
+	assert.True(t, os.IsNotExist(err))
+
+	templates, err := service.LoadTemplates(s.Config.TemplateDir)
+	require.NoError(t, err)
+	assert.Empty(t, templates)
+}
+
+func TestAdmin_Authorization(t *testing.T) {
+	s, h := setupAdminTest(t)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/admin/templates/drake", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The token alone, without the Bearer scheme, is not accepted
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/admin/templates/drake", nil)
+	req.Header.Set("Authorization", testAdminToken)
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	disabled := handler.NewAdminHandler(s, "")
+	rec = httptest.NewRecorder()
+	disabled.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/templates/drake", ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	assert.Len(t, s.TemplateCatalog(), 1)
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestLayoutText_VisualOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		rtl  bool
+	}{
+		{"Left-to-right", "hello world", "hello world", false},
+		{"Hebrew", "שלום עולם", "םלוע םולש", true},
+		{"Hebrew in English", "I said שלום עולם today", "I said םלוע םולש today", false},
+		{"English in Hebrew", "אמרתי hello world היום", "םויה hello world יתרמא", true},
+		{"Numbers in Hebrew", "שלום 123 עולם", "םלוע 123 םולש", true},
+		{"Decimal number", "מחיר 12.50", "12.50 ריחמ", true},
+		{"Numbers in Arabic in English", "I paid ثمن 100 دولار today", "I paid رالود 100 نمث today", false},
+		{"Arabic-Indic digits", "abc ١٢٣", "abc ١٢٣", false},
+		{"Mirrored brackets", "(שלום) עולם", "םלוע (םולש)", true},
+		{"Neutral paragraph", "123 !", "123 !", false},
+		{"Marks stay with their letter", "שָׁלוֹם", "םוֹלשָׁ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := service.LayoutText(basicfont.Face7x13, tt.text, service.LayoutOptions{MaxWidth: 1000, LineHeight: 20})
+			require.Len(t, layout.Lines, 1)
+			assert.Equal(t, tt.want, layout.Lines[0].Text)
+			assert.Equal(t, tt.rtl, layout.Lines[0].RTL)
+		})
+	}
+}
+
+func TestLayoutText_RTLWrapping(t *testing.T) {
+	// Lines break in reading order: the first words of a right-to-left paragraph are on the first line
+	layout := service.LayoutText(basicfont.Face7x13, "אחת שתיים שלוש ארבע", service.LayoutOptions{MaxWidth: 10 * glyphWidth, LineHeight: 20})
+	assert.Equal(t, []string{"םייתש תחא", "עברא שולש"}, lineTexts(layout))
+
+	// Mixed lines keep each run in its own direction
+	layout = service.LayoutText(basicfont.Face7x13, "Hello שלום עולם and bye", service.LayoutOptions{MaxWidth: 15 * glyphWidth, LineHeight: 20})
+	assert.Equal(t, []string{"Hello םלוע םולש", "and bye"}, lineTexts(layout))
+
+	// The ellipsis ends a truncated right-to-left line on its left
+	layout = service.LayoutText(basicfont.Face7x13, "אחת שתיים שלוש", service.LayoutOptions{MaxWidth: 10 * glyphWidth, LineHeight: 20, MaxLines: 1, Ellipsis: "…"})
+	assert.Equal(t, []string{"…םייתש תחא"}, lineTexts(layout))
+}
+
+func TestLayoutText_NaturalAlignment(t *testing.T) {
+	text := "hello\nשלום"
+	tests := []struct {
+		align          string
+		ltrMin, rtlMin int
+	}{
+		{service.AlignStart, 0, 100 - 4*glyphWidth},
+		{service.AlignEnd, 100 - 5*glyphWidth, 0},
+		{service.AlignLeft, 0, 0},
+		{service.AlignRight, 100 - 5*glyphWidth, 100 - 4*glyphWidth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.align, func(t *testing.T) {
+			layout := service.LayoutText(basicfont.Face7x13, text, service.LayoutOptions{MaxWidth: 100, LineHeight: 20, Align: tt.align})
+			require.Len(t, layout.Lines, 2)
+			assert.Equal(t, tt.ltrMin, layout.Lines[0].Bounds.Min.X)
+			assert.Equal(t, tt.rtlMin, layout.Lines[1].Bounds.Min.X)
+		})
+	}
+}
+
+// arabicWidth renders green Arabic text at 100pt with a font from widths and returns how
+// wide it is drawn. Every glyph of the font is a box as wide as its advance, a tenth of
+// its width in font units at that size.
+func arabicWidth(t *testing.T, widths map[rune]int16, text string) int {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.TemplateDir, "arabic.ttf"), boxFont(widths), 0644))
+
+	meme, err := s.Generate(context.Background(), &service.MemeRequest{
+		TemplateID: "drake",
+		Captions: []service.Caption{{Text: text, Style: &service.CaptionStyle{
+			TextStyle: service.TextStyle{Font: "arabic", Color: "#00ff00", StrokeWidth: intPtr(0)},
+			Size:      floatPtr(100),
+		}}},
+	})
+	require.NoError(t, err)
+
+	img, _, err := image.Decode(bytes.NewReader(meme.ImageData))
+	require.NoError(t, err)
+	return matchBounds(crop(img, image.Rect(0, 0, 400, 200)), isGreen).Dx()
+}
+
+func TestArabicShaping(t *testing.T) {
+	// Plain letters are 10 pixels wide; their forms are told apart by width
+	widths := map[rune]int16{
+		'ب': 100, 0xFE8F: 150, 0xFE90: 200, 0xFE91: 300, 0xFE92: 400, // Beh: isolated, final, initial, medial
+		'ل': 100, 'ا': 100, 'د': 100, 0xFEAA: 250, // Lam, alef, dal and its final form
+		' ':    100,
+		0xFEFB: 500, 0xFEFC: 600, // Lam-alef: isolated, final
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"Isolated", "ب", 15},
+		{"Initial, medial and final", "ببب", 30 + 40 + 20},
+		{"Joins across marks", "بَب", 30 + 20},
+		{"Words shape separately", "بب بب", 2*(30+20) + 10},
+		{"Right-joining letter ends the join", "بدب", 30 + 25 + 15},
+		{"Lam-alef ligature", "لا", 50},
+		{"Final lam-alef", "بلا", 30 + 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, arabicWidth(t, widths, tt.text), 3)
+		})
+	}
+}
+
+func TestArabicShaping_FontWithoutForms(t *testing.T) {
+	// Forms the font lacks are drawn as the plain letters
+	assert.InDelta(t, 30, arabicWidth(t, map[rune]int16{'ب': 100}, "ببب"), 3)
+	assert.InDelta(t, 20, arabicWidth(t, map[rune]int16{'ل': 100, 'ا': 100}, "لا"), 3)
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupBuiltinService creates a service whose template directory and font file do not exist
+func setupBuiltinService(t *testing.T) *service.MemeService {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	s := &service.MemeService{
+		Config: &config.Config{
+			TemplateDir:  filepath.Join(missing, "templates"),
+			FontFile:     filepath.Join(missing, "impact.ttf"),
+			ImageQuality: 90,
+			FontSize:     36,
+			LineSpacing:  1.5,
+		},
+	}
+	require.NoError(t, s.ReloadTemplates())
+
+	return s
+}
+
+func TestBuiltinTemplates_Fallback(t *testing.T) {
+	s := setupBuiltinService(t)
+
+	catalog := s.TemplateCatalog()
+	assert.Len(t, catalog, 4)
+	assert.Contains(t, catalog, "drake")
+	assert.Contains(t, catalog, "change-my-mind")
+}
+
+func TestBuiltinTemplates_EmptyTemplateDir(t *testing.T) {
+	// An empty directory, like a freshly mounted volume, has no manifest either
+	s := &service.MemeService{Config: &config.Config{TemplateDir: t.TempDir()}}
+	require.NoError(t, s.ReloadTemplates())
+
+	assert.Len(t, s.TemplateCatalog(), 4)
+	_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{})
+	assert.ErrorIs(t, err, service.ErrBuiltinTemplates)
+}
+
+func TestBuiltinTemplates_GenerateMeme(t *testing.T) {
+	s := setupBuiltinService(t)
+
+	// Neither the template images nor the font exist on disk
+	resp, err := s.GenerateMeme(context.Background(), &pb.GenerateMemeRequest{
+		TemplateId: "drake",
+		TopText:    "Missing fonts",
+		BottomText: "Built-in fonts",
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Error)
+
+	data, err := base64.StdEncoding.DecodeString(resp.ImageData)
+	require.NoError(t, err)
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 1200, config.Width)
+}
+
+func TestBuiltinTemplates_Preview(t *testing.T) {
+	s := setupBuiltinService(t)
+
+	data, err := s.TemplatePreview("change-my-mind.jpg", "small", true)
+	require.NoError(t, err)
+	assert.Equal(t, 160, decodePreview(t, data).Dx())
+}
+
+func TestBuiltinTemplates_ReadOnly(t *testing.T) {
+	s := setupBuiltinService(t)
+	h := handler.NewAdminHandler(s, testAdminToken)
+
+	_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{})
+	assert.ErrorIs(t, err, service.ErrBuiltinTemplates)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/templates/drake", ""))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestBuiltinTemplates_TemplateDirCreated(t *testing.T) {
+	s := setupBuiltinService(t)
+
+	// Creating the template directory replaces the built-in pack on the next reload
+	dir := writeManifestDir(t, singleTemplateManifest, "drake.jpg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(s.Config.TemplateDir), 0o755))
+	require.NoError(t, os.Rename(dir, s.Config.TemplateDir))
+	require.NoError(t, s.ReloadTemplates())
+
+	assert.Len(t, s.TemplateCatalog(), 1)
+	_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{})
+	assert.NoError(t, err)
+}
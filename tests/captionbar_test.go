@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateBars renders captions in the caption-bar layout on the 400x400 drake template
+func generateBars(t *testing.T, bar *service.CaptionBar, captions ...service.Caption) (*service.GeneratedMeme, image.Image) {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+
+	meme, err := s.Generate(context.Background(), &service.MemeRequest{
+		TemplateID: "drake",
+		Captions:   captions,
+		Layout:     service.LayoutCaptionBar,
+		CaptionBar: bar,
+	})
+	require.NoError(t, err)
+
+	img, _, err := image.Decode(bytes.NewReader(meme.ImageData))
+	require.NoError(t, err)
+	return meme, img
+}
+
+// isTemplate matches the colour of the test template image
+func isTemplate(r, g, b uint32) bool {
+	return r > 180 && r < 220 && g > 60 && g < 100 && b > 20 && b < 60
+}
+
+func TestCaptionBar_ExtendsCanvas(t *testing.T) {
+	meme, img := generateBars(t, nil, service.Caption{Text: "When the tests pass"}, service.Caption{Text: "On the first try"})
+
+	// Both bars add to the height, and the response reports the size of the image
+	assert.Equal(t, 400, meme.Width)
+	assert.Greater(t, meme.Height, 400)
+	assert.Equal(t, image.Pt(meme.Width, meme.Height), img.Bounds().Size())
+
+	// The template sits between the bars, whose text is black on white
+	template := matchBounds(img, isTemplate)
+	assert.InDelta(t, 400, template.Dy(), 2)
+	assert.Greater(t, template.Min.Y, 0)
+	assert.Less(t, template.Max.Y, meme.Height)
+
+	top := crop(img, image.Rect(0, 0, 400, template.Min.Y))
+	bottom := crop(img, image.Rect(0, template.Max.Y, 400, meme.Height))
+	for _, bar := range []image.Image{top, bottom} {
+		assert.Positive(t, countPixels(bar, isWhite))
+		assert.Positive(t, countPixels(bar, isBlack))
+	}
+}
+
+func TestCaptionBar_SizedToText(t *testing.T) {
+	short, _ := generateBars(t, nil, service.Caption{Text: "Short"})
+	long, _ := generateBars(t, nil, service.Caption{Text: "A caption long enough to wrap onto several lines of the bar"})
+
+	// Only the top bar is drawn, and it grows with the wrapped text
+	assert.Greater(t, short.Height, 400)
+	assert.Greater(t, long.Height, short.Height+20)
+
+	// No caption, no bars
+	none, _ := generateBars(t, nil, service.Caption{Text: ""})
+	assert.Equal(t, 400, none.Height)
+}
+
+func TestCaptionBar_ColorAndStyle(t *testing.T) {
+	_, img := generateBars(t,
+		&service.CaptionBar{Color: "#000", TextStyle: &service.TextStyle{Color: "#00ff00", Case: service.CaseUpper}},
+		service.Caption{Text: "Top"},
+		service.Caption{Text: "Bottom", Style: &service.CaptionStyle{TextStyle: service.TextStyle{Color: "#0000ff"}}},
+	)
+	template := matchBounds(img, isTemplate)
+
+	// The bar style applies to both bars, and a caption's own style takes precedence
+	top := crop(img, image.Rect(0, 0, 400, template.Min.Y))
+	bottom := crop(img, image.Rect(0, template.Max.Y, 400, img.Bounds().Max.Y))
+	assert.Positive(t, countPixels(top, isBlack))
+	assert.Positive(t, countPixels(top, isGreen))
+	assert.Zero(t, countPixels(top, isWhite))
+	assert.Positive(t, countPixels(bottom, isBlue))
+	assert.Zero(t, countPixels(bottom, isGreen))
+}
+
+func TestCaptionBar_OverlayReportsTemplateSize(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+
+	meme, err := s.Generate(context.Background(), &service.MemeRequest{TemplateID: "drake", Captions: []service.Caption{{Text: "Top"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 400, meme.Width)
+	assert.Equal(t, 400, meme.Height)
+}
+
+func TestCaptionBar_Validation(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+
+	tests := []struct {
+		name string
+		req  service.MemeRequest
+		want string
+	}{
+		{"Unknown layout", service.MemeRequest{Layout: "sideways"}, "layout must be overlay or caption-bar"},
+		{"Bar without layout", service.MemeRequest{CaptionBar: &service.CaptionBar{Color: "#fff"}}, "caption_bar needs the caption-bar layout"},
+		{"Additional text", service.MemeRequest{Layout: service.LayoutCaptionBar, Captions: []service.Caption{{Text: "Top"}, {}, {Text: "Middle"}}},
+			"captions[2]: the caption-bar layout only has top and bottom text"},
+		{"Bad bar colour", service.MemeRequest{Layout: service.LayoutCaptionBar, CaptionBar: &service.CaptionBar{Color: "white"}},
+			`caption_bar: colour "white" must start with #`},
+		{"Bad text style", service.MemeRequest{Layout: service.LayoutCaptionBar, CaptionBar: &service.CaptionBar{TextStyle: &service.TextStyle{Case: "lower"}}},
+			"caption_bar.text_style: case must be upper or as-typed"},
+		{"Unknown font", service.MemeRequest{Layout: service.LayoutCaptionBar, CaptionBar: &service.CaptionBar{TextStyle: &service.TextStyle{Font: "comic.ttf"}}},
+			"caption_bar.text_style: unknown font comic.ttf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.TemplateID = "drake"
+			_, err := s.Generate(context.Background(), &req)
+			require.ErrorIs(t, err, service.ErrInvalidLayout)
+			assert.Equal(t, "invalid layout: "+tt.want, err.Error())
+		})
+	}
+}
+
+func TestMemesHandler_CaptionBar(t *testing.T) {
+	h := handler.NewMemesHandler(setupFitService(t, true, service.OverflowEllipsis))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/memes", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"template_id": "drake", "layout": "caption-bar", "caption_bar": {"color": "#fff"}, "captions": [{"text": "Top"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var meme service.GeneratedMeme
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &meme))
+	assert.Equal(t, 400, meme.Width)
+	assert.Greater(t, meme.Height, 400)
+
+	rec = post(`{"template_id": "drake", "layout": "sideways", "captions": [{"text": "Top"}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "layout must be overlay or caption-bar")
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const singleTemplateManifest = `{"templates": [
+	{"id": "drake", "name": "Drake", "category": "classic", "filename": "drake.jpg", "text_field_count": 2}
+]}`
+
+const twoTemplateManifest = `{"templates": [
+	{"id": "drake", "name": "Drake", "category": "classic", "filename": "drake.jpg", "text_field_count": 2},
+	{"id": "change-my-mind", "name": "Change My Mind", "category": "debate", "filename": "change-my-mind.jpg", "text_field_count": 1}
+]}`
+
+// setupCatalogService creates a service whose catalog is loaded from a temporary template directory
+func setupCatalogService(t *testing.T, manifest string, images ...string) *service.MemeService {
+	dir := writeManifestDir(t, manifest, images...)
+
+	templates, err := service.LoadTemplates(dir)
+	require.NoError(t, err)
+
+	return &service.MemeService{
+		Config:    &config.Config{TemplateDir: dir},
+		Templates: templates,
+	}
+}
+
+func TestMemeService_ReloadTemplates(t *testing.T) {
+	s := setupCatalogService(t, singleTemplateManifest, "drake.jpg", "change-my-mind.jpg")
+
+	err := os.WriteFile(filepath.Join(s.Config.TemplateDir, service.ManifestFilename), []byte(twoTemplateManifest), 0644)
+	require.NoError(t, err)
+
+	require.NoError(t, s.ReloadTemplates())
+	assert.Len(t, s.TemplateCatalog(), 2)
+
+	_, exists := s.Template("change-my-mind")
+	assert.True(t, exists)
+}
+
+func TestMemeService_LoadCatalog_LeavesDirectoryAlone(t *testing.T) {
+	dir := writeManifestDir(t, twoTemplateManifest, "drake.jpg", "change-my-mind.jpg")
+	s := &service.MemeService{Config: &config.Config{TemplateDir: dir}}
+
+	require.NoError(t, s.LoadCatalog())
+	assert.Len(t, s.TemplateCatalog(), 2)
+
+	// Unlike ReloadTemplates, loading does not archive versions into the directory
+	_, err := os.Stat(filepath.Join(dir, service.VersionsDirname))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMemeService_ReloadTemplates_KeepsLastGoodCatalog(t *testing.T) {
+	s := setupCatalogService(t, singleTemplateManifest, "drake.jpg")
+
+	// change-my-mind.jpg does not exist, so the new manifest is rejected
+	err := os.WriteFile(filepath.Join(s.Config.TemplateDir, service.ManifestFilename), []byte(twoTemplateManifest), 0644)
+	require.NoError(t, err)
+
+	err = s.ReloadTemplates()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "change-my-mind.jpg not found")
+
+	assert.Len(t, s.TemplateCatalog(), 1)
+	_, exists := s.Template("drake")
+	assert.True(t, exists)
+}
+
+func TestMemeService_WatchTemplates(t *testing.T) {
+	s := setupCatalogService(t, singleTemplateManifest, "drake.jpg")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.WatchTemplates(ctx, 10*time.Millisecond)
+
+	// Let the watcher take its initial snapshot before changing the directory
+	time.Sleep(50 * time.Millisecond)
+
+	err := os.WriteFile(filepath.Join(s.Config.TemplateDir, "change-my-mind.jpg"), []byte("image"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(s.Config.TemplateDir, service.ManifestFilename), []byte(twoTemplateManifest), 0644)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, exists := s.Template("change-my-mind")
+		return exists
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestMemeService_ConcurrentReload(t *testing.T) {
+	s := setupCatalogService(t, twoTemplateManifest, "drake.jpg", "change-my-mind.jpg")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NoError(t, s.ReloadTemplates())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				resp, err := s.ListTemplates(context.Background(), &pb.ListTemplatesRequest{})
+				assert.NoError(t, err)
+				assert.Len(t, resp.Templates, 2)
+			}
+		}()
+	}
+	wg.Wait()
+}
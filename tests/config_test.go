@@ -26,10 +26,18 @@ func TestLoadConfig(t *testing.T) {
 		// Check default values
 		assert.Equal(t, "50051", cfg.Port)
 		assert.Equal(t, "./templates", cfg.TemplateDir)
+		assert.Equal(t, 30*time.Second, cfg.TemplateReloadInterval)
 		assert.Equal(t, "./fonts/impact.ttf", cfg.FontFile)
+		assert.Equal(t, "fonts", cfg.FontDir)
+		assert.Empty(t, cfg.FontFallbacks)
+		assert.Equal(t, "./emoji", cfg.EmojiDir)
 		assert.Equal(t, 90, cfg.ImageQuality)
 		assert.Equal(t, 36.0, cfg.FontSize)
 		assert.Equal(t, 1.5, cfg.LineSpacing)
+		assert.True(t, cfg.AutoFitText)
+		assert.Equal(t, 12.0, cfg.MinFontSize)
+		assert.Equal(t, 48.0, cfg.MaxFontSize)
+		assert.Equal(t, "ellipsis", cfg.TextOverflow)
 		assert.False(t, cfg.EnableAICaption)
 	})
 
@@ -51,6 +59,7 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, "8080", cfg.Port)
 		assert.Equal(t, "/custom/templates", cfg.TemplateDir)
 		assert.Equal(t, "/custom/fonts/comic.ttf", cfg.FontFile)
+		assert.Equal(t, "/custom/fonts", cfg.FontDir)
 		assert.Equal(t, 75, cfg.ImageQuality)
 		assert.Equal(t, 42.0, cfg.FontSize)
 		assert.Equal(t, 2.0, cfg.LineSpacing)
@@ -75,6 +84,7 @@ func TestLoadConfig(t *testing.T) {
 		os.Setenv("LINE_SPACING", "invalid")
 		os.Setenv("ENABLE_AI_CAPTION", "invalid")
 		os.Setenv("GRPC_MAX_CONNECTION_AGE", "invalid")
+		os.Setenv("TEXT_OVERFLOW", "rejct")
 
 		cfg := config.LoadConfig()
 
@@ -84,6 +94,7 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, 1.5, cfg.LineSpacing)
 		assert.False(t, cfg.EnableAICaption)
 		assert.Equal(t, 30*time.Minute, cfg.GRPCMaxConnectionAge)
+		assert.Equal(t, "ellipsis", cfg.TextOverflow)
 
 		// Clean up environment
 		os.Unsetenv("IMAGE_QUALITY")
@@ -91,6 +102,7 @@ func TestLoadConfig(t *testing.T) {
 		os.Unsetenv("LINE_SPACING")
 		os.Unsetenv("ENABLE_AI_CAPTION")
 		os.Unsetenv("GRPC_MAX_CONNECTION_AGE")
+		os.Unsetenv("TEXT_OVERFLOW")
 	})
 }
 
@@ -124,5 +136,29 @@ func TestHelperFunctions(t *testing.T) {
 		assert.Equal(t, 10, config.GetIntEnv("NON_EXISTENT_VAR", 10))
 	})
 
+	t.Run("getListEnv", func(t *testing.T) {
+		os.Setenv("TEST_LIST", " NotoSansJP, ,NotoEmoji ")
+		defer os.Unsetenv("TEST_LIST")
+
+		// Items are trimmed and empty items dropped
+		assert.Equal(t, []string{"NotoSansJP", "NotoEmoji"}, config.GetListEnv("TEST_LIST", nil))
+
+		// Test non-existent env var
+		assert.Equal(t, []string{"default"}, config.GetListEnv("NON_EXISTENT_VAR", []string{"default"}))
+	})
+
+	t.Run("getChoiceEnv", func(t *testing.T) {
+		os.Setenv("TEST_CHOICE", "reject")
+		os.Setenv("TEST_INVALID_CHOICE", "rejct")
+		defer func() {
+			os.Unsetenv("TEST_CHOICE")
+			os.Unsetenv("TEST_INVALID_CHOICE")
+		}()
+
+		assert.Equal(t, "reject", config.GetChoiceEnv("TEST_CHOICE", "ellipsis", "ellipsis", "reject"))
+		assert.Equal(t, "ellipsis", config.GetChoiceEnv("TEST_INVALID_CHOICE", "ellipsis", "ellipsis", "reject"))
+		assert.Equal(t, "ellipsis", config.GetChoiceEnv("NON_EXISTENT_VAR", "ellipsis", "ellipsis", "reject"))
+	})
+
 	// Add more tests for other helper functions as needed
 }
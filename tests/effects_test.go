@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"image"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderEffect renders a large "Hi" as the bottom caption of the drake template in green
+// without an outline, with the shadow and glow of style, and returns the bottom half
+func renderEffect(t *testing.T, style service.TextStyle) image.Image {
+	style.Color = "#00ff00"
+	style.StrokeWidth = intPtr(0)
+	img := generateStyled(t,
+		service.Caption{Text: "Top"},
+		service.Caption{Text: "Hi", Style: &service.CaptionStyle{TextStyle: style, Size: floatPtr(80)}},
+	)
+	return crop(img, image.Rect(0, 200, 400, 400))
+}
+
+// bluer returns the pixels that are clearly bluer in img than in plain, the same caption
+// drawn without effects, and the smallest rectangle holding them
+func bluer(plain, img image.Image) (count int, found image.Rectangle) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			_, _, before, _ := plain.At(x, y).RGBA()
+			_, _, after, _ := img.At(x, y).RGBA()
+			if after>>8 > before>>8+48 {
+				count++
+				found = found.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return count, found
+}
+
+func TestShadow_Offset(t *testing.T) {
+	plain := renderEffect(t, service.TextStyle{})
+	img := renderEffect(t, service.TextStyle{Shadow: &service.TextShadow{OffsetX: 6, OffsetY: 4, Color: "#0000ff"}})
+	text := matchBounds(plain, isGreen)
+	_, shadow := bluer(plain, img)
+	require.False(t, text.Empty())
+	require.False(t, shadow.Empty())
+
+	// A hard shadow is the text moved by the offset; the text hides the rest of it. JPEG
+	// blurs the edges by a few pixels.
+	assert.InDelta(t, text.Max.X+6, shadow.Max.X, 4)
+	assert.InDelta(t, text.Max.Y+4, shadow.Max.Y, 4)
+	assert.Greater(t, shadow.Min.X, text.Min.X)
+}
+
+func TestShadow_Blur(t *testing.T) {
+	plain := renderEffect(t, service.TextStyle{})
+	hardCount, hard := bluer(plain, renderEffect(t, service.TextStyle{Shadow: &service.TextShadow{OffsetY: 4, Color: "#0000ff"}}))
+	softCount, soft := bluer(plain, renderEffect(t, service.TextStyle{Shadow: &service.TextShadow{OffsetY: 4, Blur: 8, Color: "#0000ff"}}))
+
+	// The hard shadow only shows below the text; blurring spreads it out from behind the text
+	assert.Greater(t, softCount, 2*hardCount)
+	assert.Greater(t, soft.Max.Y, hard.Max.Y)
+}
+
+func TestShadow_UnderStrokeAndFill(t *testing.T) {
+	plain := generateStyled(t, service.Caption{Text: "Top"}, service.Caption{Text: "Hi", Style: &service.CaptionStyle{
+		TextStyle: service.TextStyle{Color: "#00ff00", StrokeColor: "#000000", StrokeWidth: intPtr(4)},
+	}})
+	shadowed := generateStyled(t, service.Caption{Text: "Top"}, service.Caption{Text: "Hi", Style: &service.CaptionStyle{
+		TextStyle: service.TextStyle{Color: "#00ff00", StrokeColor: "#000000", StrokeWidth: intPtr(4),
+			Shadow: &service.TextShadow{Blur: 6, Color: "#0000ff"}},
+	}})
+
+	// The shadow shows around the outline without covering the outline or the text
+	count, _ := bluer(plain, shadowed)
+	assert.Positive(t, count)
+	assert.InEpsilon(t, countPixels(plain, isGreen), countPixels(shadowed, isGreen), 0.05)
+	assert.InEpsilon(t, countPixels(plain, isBlack), countPixels(shadowed, isBlack), 0.05)
+}
+
+func TestGlow_SurroundsText(t *testing.T) {
+	plain := renderEffect(t, service.TextStyle{})
+	img := renderEffect(t, service.TextStyle{Glow: &service.TextGlow{Radius: 12, Color: "#0000ff"}})
+	text := matchBounds(plain, isGreen)
+	_, glow := bluer(plain, img)
+	require.False(t, text.Empty())
+
+	assert.Less(t, glow.Min.X, text.Min.X-4)
+	assert.Less(t, glow.Min.Y, text.Min.Y-4)
+	assert.Greater(t, glow.Max.X, text.Max.X+4)
+	assert.Greater(t, glow.Max.Y, text.Max.Y+4)
+	assert.Positive(t, countPixels(img, isGreen))
+}
+
+func TestEffects_ZeroOpacity(t *testing.T) {
+	plain := renderEffect(t, service.TextStyle{})
+	hidden := renderEffect(t, service.TextStyle{
+		Shadow: &service.TextShadow{OffsetX: 3, OffsetY: 3, Blur: 4, Opacity: floatPtr(0)},
+		Glow:   &service.TextGlow{Radius: 8, Opacity: floatPtr(0)},
+	})
+
+	assert.Equal(t, plain, hidden)
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sprite encodes a square PNG sprite of a single colour
+func sprite(t *testing.T, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 72, 72))
+	for y := 0; y < 72; y++ {
+		for x := 0; x < 72; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func isRed(r, g, b uint32) bool    { return r > 200 && g < 60 && b < 60 }
+func isYellow(r, g, b uint32) bool { return r > 200 && g > 200 && b < 60 }
+
+// setupEmojiService returns a fit test service with a few single-colour emoji sprites
+func setupEmojiService(t *testing.T) *service.MemeService {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+	s.Config.EmojiDir = t.TempDir()
+
+	sprites := map[string]color.Color{
+		"1f600":                       color.RGBA{0xff, 0, 0, 0xff},    // 😀
+		"1f468":                       color.RGBA{0, 0xff, 0, 0xff},    // 👨
+		"1f469":                       color.RGBA{0, 0xff, 0, 0xff},    // 👩
+		"1f468-200d-1f469-200d-1f467": color.RGBA{0, 0, 0xff, 0xff},    // 👨‍👩‍👧
+		"1f44d":                       color.RGBA{0, 0xff, 0, 0xff},    // 👍
+		"1f44d-1f3fd":                 color.RGBA{0xff, 0xff, 0, 0xff}, // 👍🏽
+		"2764-fe0f":                   color.RGBA{0xff, 0, 0, 0xff},    // ❤️
+	}
+	for name, c := range sprites {
+		require.NoError(t, os.WriteFile(filepath.Join(s.Config.EmojiDir, name+".png"), sprite(t, c), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.EmojiDir, "README.png"), []byte("not a sprite"), 0644))
+	return s
+}
+
+// renderEmoji renders a white caption with a blue outline in the top half of the drake template
+func renderEmoji(t *testing.T, s *service.MemeService, text string) image.Image {
+	meme, err := s.Generate(context.Background(), &service.MemeRequest{
+		TemplateID: "drake",
+		Captions: []service.Caption{{Text: text, Style: &service.CaptionStyle{
+			TextStyle: service.TextStyle{StrokeColor: "#0000ff", StrokeWidth: intPtr(3)},
+			Size:      floatPtr(40),
+		}}},
+	})
+	require.NoError(t, err)
+
+	img, _, err := image.Decode(bytes.NewReader(meme.ImageData))
+	require.NoError(t, err)
+	return crop(img, image.Rect(0, 0, 400, 200))
+}
+
+func TestEmoji_DrawnInlineWithoutOutline(t *testing.T) {
+	s := setupEmojiService(t)
+
+	img := renderEmoji(t, s, "Hi 😀 there")
+	emoji := matchBounds(img, isRed)
+	text := matchBounds(img, isWhite)
+	require.False(t, emoji.Empty())
+
+	// The emoji sits on the line between the words, about as tall as the font size
+	assert.Less(t, text.Min.X, emoji.Min.X)
+	assert.Greater(t, text.Max.X, emoji.Max.X)
+	assert.InDelta(t, 40, emoji.Dy(), 4)
+	assert.InDelta(t, (text.Min.Y+text.Max.Y)/2, (emoji.Min.Y+emoji.Max.Y)/2, 6)
+
+	// Only the text is outlined
+	assert.Zero(t, countPixels(renderEmoji(t, s, "😀"), isBlue))
+}
+
+func TestEmoji_Sequences(t *testing.T) {
+	s := setupEmojiService(t)
+
+	tests := []struct {
+		name  string
+		text  string
+		match func(r, g, b uint32) bool
+	}{
+		{"ZWJ sequence", "👨‍👩‍👧", isBlue},
+		{"Skin tone", "👍🏽", isYellow},
+		{"Variation selector", "❤️", isRed},
+		{"Without variation selector", "❤", isRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := renderEmoji(t, s, tt.text)
+			assert.Positive(t, countPixels(img, tt.match))
+			assert.Zero(t, countPixels(img, isGreen), "drawn from its parts")
+		})
+	}
+
+	// A sequence without a sprite of its own falls back to its parts, side by side
+	parts := matchBounds(renderEmoji(t, s, "👨‍👩"), isGreen)
+	assert.InDelta(t, 80, parts.Dx(), 6)
+}
+
+// isTwemojiYellow matches the face colour of the built-in smileys, #ffcc4d
+func isTwemojiYellow(r, g, b uint32) bool {
+	return r > 230 && g > 180 && g < 225 && b > 40 && b < 120
+}
+
+func TestEmoji_MissingDirectory(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+	s.Config.EmojiDir = filepath.Join(t.TempDir(), "missing")
+
+	// The built-in sprites are drawn instead, in colour and without an outline
+	img := renderEmoji(t, s, "Hi 😀")
+	assert.Positive(t, countPixels(img, isWhite))
+	assert.Positive(t, countPixels(img, isTwemojiYellow))
+}
+
+func TestEmoji_EmptyDirectory(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+	s.Config.EmojiDir = t.TempDir()
+
+	// An emoji directory without sprites turns them off rather than falling back
+	img := renderEmoji(t, s, "Hi 😀")
+	assert.Positive(t, countPixels(img, isWhite))
+	assert.Zero(t, countPixels(img, isTwemojiYellow))
+}
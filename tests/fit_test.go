@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// longCaption wraps to far more lines than fit half of a 400x400 image at the starting size
+var longCaption = strings.Repeat("the caption that would not end ", 6)
+
+// setupFitService creates a version test service with a square 400x400 drake template
+func setupFitService(t *testing.T, autoFit bool, overflow string) *service.MemeService {
+	s := setupVersionService(t)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.TemplateDir, "drake.jpg"), testImage(t, "jpeg", 400, 400), 0644))
+	require.NoError(t, s.ReloadTemplates())
+
+	s.Config.AutoFitText = autoFit
+	s.Config.TextOverflow = overflow
+	return s
+}
+
+// decodeMeme decodes the image of a successful generate response
+func decodeMeme(t *testing.T, resp *pb.GenerateMemeResponse) image.Image {
+	require.Empty(t, resp.Error)
+
+	data, err := base64.StdEncoding.DecodeString(resp.ImageData)
+	require.NoError(t, err)
+	img, _, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	return img
+}
+
+// crop returns the part of img inside rect
+func crop(img image.Image, rect image.Rectangle) image.Image {
+	return img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}).SubImage(rect)
+}
+
+func TestAutoFit_ShrinksIntoHalf(t *testing.T) {
+	req := &pb.GenerateMemeRequest{TemplateId: "drake", TopText: longCaption}
+
+	// At a fixed size the top caption runs into the bottom half
+	fixed, err := setupFitService(t, false, service.OverflowVisible).GenerateMeme(context.Background(), req)
+	require.NoError(t, err)
+	assert.Positive(t, countPixels(crop(decodeMeme(t, fixed), image.Rect(0, 220, 400, 400)), isWhite))
+
+	// Auto-fit shrinks it until every line stays in the top half
+	fitted, err := setupFitService(t, true, service.OverflowVisible).GenerateMeme(context.Background(), req)
+	require.NoError(t, err)
+	img := decodeMeme(t, fitted)
+	assert.Positive(t, countPixels(crop(img, image.Rect(0, 0, 400, 200)), isWhite))
+	assert.Zero(t, countPixels(crop(img, image.Rect(0, 200, 400, 400)), isWhite))
+}
+
+func TestAutoFit_OverflowPolicies(t *testing.T) {
+	// Too long to fit even at the minimum font size
+	req := &pb.GenerateMemeRequest{TemplateId: "drake", TopText: strings.Repeat(longCaption, 10)}
+
+	t.Run("Ellipsis", func(t *testing.T) {
+		resp, err := setupFitService(t, true, service.OverflowEllipsis).GenerateMeme(context.Background(), req)
+		require.NoError(t, err)
+		assert.Zero(t, countPixels(crop(decodeMeme(t, resp), image.Rect(0, 200, 400, 400)), isWhite))
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		resp, err := setupFitService(t, true, service.OverflowVisible).GenerateMeme(context.Background(), req)
+		require.NoError(t, err)
+		assert.Positive(t, countPixels(crop(decodeMeme(t, resp), image.Rect(0, 200, 400, 400)), isWhite))
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		resp, err := setupFitService(t, true, service.OverflowReject).GenerateMeme(context.Background(), req)
+		require.NoError(t, err)
+		assert.Empty(t, resp.ImageData)
+		assert.Contains(t, resp.Error, "caption does not fit: top text is too long for its 400x")
+		assert.Contains(t, resp.Error, "even at 12pt")
+	})
+
+	t.Run("Reject accepts captions that fit", func(t *testing.T) {
+		resp, err := setupFitService(t, true, service.OverflowReject).GenerateMeme(context.Background(), &pb.GenerateMemeRequest{TemplateId: "drake", TopText: longCaption})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Error)
+	})
+}
+
+func TestAutoFit_MinFontSize(t *testing.T) {
+	req := &pb.GenerateMemeRequest{TemplateId: "drake", TopText: longCaption}
+
+	// The caption fits at the default minimum, but not when the minimum is raised
+	s := setupFitService(t, true, service.OverflowReject)
+	s.Config.MinFontSize = 30
+	resp, err := s.GenerateMeme(context.Background(), req)
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "even at 30pt")
+}
+
+func TestAutoFit_Regions(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowReject)
+	regions := []service.TextRegion{{Name: "sign", X: 50, Y: 50, Width: 300, Height: 100, MaxLines: 2}}
+	_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{TextRegions: &regions})
+	require.NoError(t, err)
+
+	// Shrinking keeps a short caption within the line limit of the region
+	resp, err := s.GenerateMeme(context.Background(), &pb.GenerateMemeRequest{TemplateId: "drake", TopText: "one two three four five six seven"})
+	require.NoError(t, err)
+	img := decodeMeme(t, resp)
+	assert.Positive(t, countPixels(crop(img, image.Rect(50, 50, 350, 150)), isWhite))
+	assert.Zero(t, countPixels(crop(img, image.Rect(0, 160, 400, 400)), isWhite))
+
+	// A caption that needs more lines than the region allows is rejected by name
+	resp, err = s.GenerateMeme(context.Background(), &pb.GenerateMemeRequest{TemplateId: "drake", TopText: longCaption})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "text region 'sign' is too long for its 300x100 box")
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/RoMalms10/meme-generator/assets"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// squareRune is the only rune squareFont has a glyph for. The bundled font has none.
+const squareRune = '中'
+
+// squareFont builds a font whose only glyph is a filled square, mapped to squareRune
+func squareFont() []byte {
+	return boxFont(map[rune]int16{squareRune: 800})
+}
+
+// boxFont builds a minimal TrueType font, 1000 units per em, with a glyph for every rune
+// of widths: a filled box as wide as its advance and 800 units tall, on the baseline
+func boxFont(widths map[rune]int16) []byte {
+	be := func(values ...any) []byte {
+		var buf bytes.Buffer
+		for _, v := range values {
+			binary.Write(&buf, binary.BigEndian, v)
+		}
+		return buf.Bytes()
+	}
+
+	runes := make([]rune, 0, len(widths))
+	for r := range widths {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	// Glyph 0, for runes without a glyph, is empty and takes no room; glyph i+1 draws runes[i] as one clockwise contour of four
+	// on-curve points, given as relative coordinates
+	cmap := be(uint16(0), uint16(1), uint16(0), uint16(4), uint32(12), // Unicode full repertoire subtable
+		uint16(12), uint16(0), uint32(16+12*len(runes)), uint32(0), uint32(len(runes)))
+	glyf, loca, hmtx := []byte{}, be(uint32(0), uint32(0)), be(uint16(0), int16(0))
+	widest := int16(0)
+	for i, r := range runes {
+		width := widths[r]
+		widest = max(widest, width)
+		cmap = append(cmap, be(uint32(r), uint32(r), uint32(i+1))...)
+		glyf = append(glyf, be(
+			int16(1), int16(0), int16(0), width, int16(800), // Contours and bounds
+			uint16(3), uint16(0), // Last point of the contour, no instructions
+			[]uint8{1, 1, 1, 1},
+			[]int16{0, 0, width, 0},
+			[]int16{0, 800, 0, -800},
+		)...)
+		loca = append(loca, be(uint32(len(glyf)))...)
+		hmtx = append(hmtx, be(uint16(width), int16(0))...)
+	}
+	glyphs := uint16(len(runes) + 1)
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{
+		{"cmap", cmap},
+		{"glyf", glyf},
+		{"head", be(uint32(0x00010000), uint32(0x00010000), uint32(0), uint32(0x5F0F3CF5),
+			uint16(0), uint16(1000), uint64(0), uint64(0),
+			int16(0), int16(-200), widest, int16(800),
+			uint16(0), uint16(8), int16(2), int16(1), int16(0))},
+		{"hhea", be(uint32(0x00010000), int16(800), int16(-200), int16(0), uint16(widest),
+			int16(0), int16(0), widest, int16(1), int16(0), int16(0),
+			[5]int16{}, glyphs)},
+		{"hmtx", hmtx},
+		{"loca", loca},
+		{"maxp", be(uint32(0x00010000), glyphs, uint16(4), uint16(1), uint16(0), uint16(0),
+			uint16(2), [8]uint16{})},
+	}
+
+	offset := 12 + 16*len(tables)
+	header := be(uint32(0x00010000), uint16(len(tables)), uint16(0), uint16(0), uint16(0))
+	var data []byte
+	for _, table := range tables {
+		header = append(header, be([]byte(table.tag), uint32(0), uint32(offset+len(data)), uint32(len(table.data)))...)
+		data = append(data, table.data...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+	return append(header, data...)
+}
+
+// setupFontService returns a fit test service with the bundled font and squareFont in its font directory
+func setupFontService(t *testing.T) *service.MemeService {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+	s.Config.FontDir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.FontDir, "Oswald.ttf"), assets.Font(), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.FontDir, "square.ttf"), squareFont(), 0644))
+	return s
+}
+
+// renderCaption renders a green caption without an outline in the top half of the drake template
+func renderCaption(t *testing.T, s *service.MemeService, text, font string) image.Image {
+	meme, err := s.Generate(context.Background(), &service.MemeRequest{
+		TemplateID: "drake",
+		Captions: []service.Caption{{Text: text, Style: &service.CaptionStyle{
+			TextStyle: service.TextStyle{Font: font, Color: "#00ff00", StrokeWidth: intPtr(0)},
+			Size:      floatPtr(40),
+		}}},
+	})
+	require.NoError(t, err)
+
+	img, _, err := image.Decode(bytes.NewReader(meme.ImageData))
+	require.NoError(t, err)
+	return crop(img, image.Rect(0, 0, 400, 200))
+}
+
+func TestFontRegistry(t *testing.T) {
+	s := setupFontService(t)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.FontDir, "broken.ttf"), []byte("not a font"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.FontDir, "notes.txt"), []byte("not a font either"), 0644))
+
+	// Unparsable fonts and other files are skipped
+	assert.Equal(t, []string{"oswald", "square"}, s.Fonts())
+
+	// Fonts are selected by name, in any case and with or without the extension
+	for _, name := range []string{"Oswald", "oswald", "Oswald.ttf", "square.TTF"} {
+		_, err := s.Generate(context.Background(), &service.MemeRequest{
+			TemplateID: "drake",
+			Captions:   []service.Caption{{Text: "Top", Style: &service.CaptionStyle{TextStyle: service.TextStyle{Font: name}}}},
+		})
+		assert.NoError(t, err, name)
+	}
+
+	// Fonts added later are picked up on reload
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.FontDir, "other.ttf"), assets.Font(), 0644))
+	assert.NotContains(t, s.Fonts(), "other")
+	require.NoError(t, s.ReloadTemplates())
+	assert.Contains(t, s.Fonts(), "other")
+}
+
+func TestFontRegistry_SelectsFont(t *testing.T) {
+	s := setupFontService(t)
+
+	// The square font draws a solid square, the bundled font only a small box outline
+	bundled := countPixels(renderCaption(t, s, string(squareRune), "Oswald"), isGreen)
+	square := countPixels(renderCaption(t, s, string(squareRune), "square"), isGreen)
+	assert.Greater(t, square, 2*bundled)
+}
+
+func TestBuiltinFont_FallsBackForCyrillic(t *testing.T) {
+	s := setupFontService(t)
+
+	// The built-in font has no Cyrillic, so without a font file its fallback draws it;
+	// the same font loaded from FONT_DIR has no such fallback and draws empty boxes
+	builtin := countPixels(renderCaption(t, s, "ЖЖЖ", ""), isGreen)
+	boxes := countPixels(renderCaption(t, s, "ЖЖЖ", "Oswald"), isGreen)
+	assert.Greater(t, builtin, 2*boxes)
+}
+
+func TestFontFallback_DrawsMissingGlyphs(t *testing.T) {
+	s := setupFontService(t)
+	text := "Hi " + string(squareRune)
+
+	without := renderCaption(t, s, text, "Oswald")
+	s.Config.FontFallbacks = []string{"missing", "square"}
+	with := renderCaption(t, s, text, "Oswald")
+
+	// The square comes from the fallback, next to the text of the primary font
+	assert.Greater(t, countPixels(with, isGreen), countPixels(without, isGreen)+500)
+
+	// Runes the primary font has keep its glyphs
+	hi := countPixels(renderCaption(t, s, "Hi", "Oswald"), isGreen)
+	s.Config.FontFallbacks = nil
+	assert.Equal(t, hi, countPixels(renderCaption(t, s, "Hi", "Oswald"), isGreen))
+}
+
+func TestFontRegistry_WatchPicksUpNewFonts(t *testing.T) {
+	s := setupFontService(t)
+	generate := func() error {
+		_, err := s.Generate(context.Background(), &service.MemeRequest{
+			TemplateID: "drake",
+			Captions:   []service.Caption{{Text: "Top", Style: &service.CaptionStyle{TextStyle: service.TextStyle{Font: "added.ttf"}}}},
+		})
+		return err
+	}
+	require.ErrorIs(t, generate(), service.ErrInvalidCaption)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.WatchTemplates(ctx, 10*time.Millisecond)
+
+	// Let the watcher take its initial snapshot before adding the font
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.FontDir, "added.ttf"), assets.Font(), 0644))
+
+	assert.Eventually(t, func() bool { return generate() == nil }, 2*time.Second, 10*time.Millisecond)
+}
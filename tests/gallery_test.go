@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGallery(t *testing.T) {
+	s := setupBuiltinService(t)
+	out := t.TempDir()
+
+	report, err := s.WriteGallery(out, "small")
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Templates)
+	assert.Empty(t, report.Failed)
+
+	page, err := os.ReadFile(report.Index)
+	require.NoError(t, err)
+	html := string(page)
+
+	for id := range s.TemplateCatalog() {
+		assert.Contains(t, html, "<code>"+id+"</code>")
+		assert.Contains(t, html, `src="thumbnails/`+id+`.jpg"`)
+
+		data, err := os.ReadFile(filepath.Join(out, service.GalleryThumbnailDir, id+".jpg"))
+		require.NoError(t, err)
+		config, format, err := image.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.LessOrEqual(t, max(config.Width, config.Height), service.PreviewSizes["small"])
+	}
+
+	// Categories, field counts and example captions are listed for each template
+	assert.Contains(t, html, "<dt>Categories</dt><dd>classic, reaction</dd>")
+	assert.Contains(t, html, "<dt>Text fields</dt><dd>3</dd>")
+	assert.Contains(t, html, "<li>Tabs are better than spaces</li>")
+}
+
+func TestWriteGallery_EscapesMetadata(t *testing.T) {
+	s := setupVersionService(t)
+	name := `<script>alert("drake")</script>`
+	_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{Name: &name})
+	require.NoError(t, err)
+
+	report, err := s.WriteGallery(t.TempDir(), "small")
+	require.NoError(t, err)
+
+	page, err := os.ReadFile(report.Index)
+	require.NoError(t, err)
+	assert.NotContains(t, string(page), name)
+	assert.Contains(t, string(page), "&lt;script&gt;")
+}
+
+func TestWriteGallery_RenderFailure(t *testing.T) {
+	s := setupCatalogService(t, twoTemplateManifest, "drake.jpg", "change-my-mind.jpg")
+	s.Config.ImageQuality = 90
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.TemplateDir, "drake.jpg"), testImage(t, "jpeg", 200, 100), 0644))
+
+	// change-my-mind.jpg is not a decodable image, so only drake makes it into the gallery
+	report, err := s.WriteGallery(t.TempDir(), "small")
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Templates)
+	require.Len(t, report.Failed, 1)
+	assert.Contains(t, report.Failed[0], "change-my-mind")
+}
+
+func TestExampleCaptions(t *testing.T) {
+	dir := writeManifestDir(t, `{"templates": [
+		{"id": "drake", "name": "Drake", "category": "classic", "filename": "drake.jpg", "text_field_count": 2,
+		 "example_captions": ["Writing docs", "Reading the source"]}
+	]}`, "drake.jpg")
+	templates, err := service.LoadTemplates(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Writing docs", "Reading the source"}, templates["drake"].ExampleCaptions)
+
+	_, err = service.LoadTemplates(writeManifestDir(t, `{"templates": [
+		{"id": "drake", "name": "Drake", "category": "classic", "filename": "drake.jpg", "text_field_count": 1,
+		 "example_captions": ["Writing docs", "Reading the source"]}
+	]}`, "drake.jpg"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "example_captions has 2 captions but the template has 1 text fields")
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImgflipCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string
+	}{
+		{
+			name:    "get_memes response",
+			catalog: `{"success": true, "data": {"memes": [{"id": "181913649", "name": "Drake Hotline Bling", "url": "https://i.imgflip.com/30b1gx.jpg", "width": 1200, "height": 1200, "box_count": 2}]}}`,
+		},
+		{
+			name:    "Bare array",
+			catalog: `[{"id": "181913649", "name": "Drake Hotline Bling", "url": "https://i.imgflip.com/30b1gx.jpg", "width": 1200, "height": 1200, "box_count": 2}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := service.ParseImgflipCatalog(strings.NewReader(tt.catalog))
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, service.ImgflipTemplate{
+				ID: "181913649", Name: "Drake Hotline Bling", URL: "https://i.imgflip.com/30b1gx.jpg",
+				Width: 1200, Height: 1200, BoxCount: 2,
+			}, entries[0])
+		})
+	}
+
+	_, err := service.ParseImgflipCatalog(strings.NewReader("not json"))
+	assert.Error(t, err)
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Drake Hotline Bling":        "drake-hotline-bling",
+		"Y'all Got Any More Of That": "yall-got-any-more-of-that",
+		"  Is This A Pigeon?  ":      "is-this-a-pigeon",
+		"Pokémon Café":               "pokemon-cafe",
+		"10 Guy":                     "10-guy",
+		"Mocking--SpongeBob":         "mocking-spongebob",
+		"ドラゴン":                       "",
+	}
+
+	for name, expected := range tests {
+		assert.Equal(t, expected, service.Slugify(name), name)
+	}
+}
+
+func TestImportImgflipTemplates(t *testing.T) {
+	s, _ := setupAdminTest(t)
+
+	imageDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(imageDir, "30b1gx.jpg"), testImage(t, "jpeg", 200, 200), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(imageDir, "87743020.png"), testImage(t, "png", 200, 120), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(imageDir, "zero-boxes.jpg"), testImage(t, "jpeg", 200, 200), 0644))
+
+	entries := []service.ImgflipTemplate{
+		// Found by the file name in its URL, but the id is taken by the existing template
+		{ID: "181913649", Name: "Drake", URL: "https://i.imgflip.com/30b1gx.jpg", BoxCount: 2},
+		// Found by its source id
+		{ID: "87743020", Name: "Two Buttons", URL: "https://i.imgflip.com/1g8my4.jpg", BoxCount: 3},
+		// Found by its slug, but without any text fields
+		{ID: "1", Name: "Zero Boxes", BoxCount: 0},
+		{ID: "2", Name: "No Image", BoxCount: 2},
+	}
+
+	results := s.ImportImgflipTemplates(entries, imageDir, "imported")
+	require.Len(t, results, 4)
+
+	assert.Equal(t, "drake", results[0].TemplateID)
+	assert.Equal(t, service.ImportConflict, results[0].Status)
+	assert.Equal(t, service.ImportImported, results[1].Status)
+	assert.Equal(t, service.ImportFailed, results[2].Status)
+	assert.Contains(t, results[2].Message, "text_field_count")
+	assert.Equal(t, service.ImportFailed, results[3].Status)
+	assert.Contains(t, results[3].Message, "no image found")
+
+	// The existing template is untouched and the import is persisted
+	drake, _ := s.Template("drake")
+	assert.Equal(t, "Drake", drake.Name)
+	assert.Equal(t, "drake.jpg", drake.Filename)
+
+	templates, err := service.LoadTemplates(s.Config.TemplateDir)
+	require.NoError(t, err)
+	require.Contains(t, templates, "two-buttons")
+	assert.Equal(t, int32(3), templates["two-buttons"].TextFieldCount)
+	assert.Equal(t, "imported", templates["two-buttons"].Category)
+	assert.Equal(t, "two-buttons.png", templates["two-buttons"].Filename)
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// imageChecksum is the SHA-256 of the placeholder images written by writeManifestDir
+var imageChecksum = func() string {
+	sum := sha256.Sum256([]byte("image"))
+	return hex.EncodeToString(sum[:])
+}()
+
+const badChecksum = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// checksumManifest declares drake with a matching checksum and change-my-mind with the given one
+func checksumManifest(changeMyMindChecksum string) string {
+	return fmt.Sprintf(`{"templates": [
+		{"id": "drake", "name": "Drake", "category": "classic", "filename": "drake.jpg", "text_field_count": 2, "sha256": "%s"},
+		{"id": "change-my-mind", "name": "Change My Mind", "category": "debate", "filename": "change-my-mind.jpg", "text_field_count": 1, "sha256": "%s"}
+	]}`, imageChecksum, changeMyMindChecksum)
+}
+
+// setupIntegrityService loads a template directory the way the service does, quarantine included
+func setupIntegrityService(t *testing.T, manifest string) *service.MemeService {
+	dir := writeManifestDir(t, manifest, "drake.jpg", "change-my-mind.jpg")
+
+	s := &service.MemeService{Config: &config.Config{
+		TemplateDir:          dir,
+		MaxTemplateBytes:     1 << 20,
+		MaxTemplateDimension: 1024,
+	}}
+	require.NoError(t, s.ReloadTemplates())
+	return s
+}
+
+func TestChecksum_Verified(t *testing.T) {
+	s := setupIntegrityService(t, checksumManifest(imageChecksum))
+
+	assert.Len(t, s.TemplateCatalog(), 2)
+	assert.Empty(t, s.QuarantinedTemplates())
+	assert.Equal(t, service.HealthOK, s.Health().Status)
+}
+
+func TestChecksum_MissingIsRecorded(t *testing.T) {
+	s := setupIntegrityService(t, twoTemplateManifest)
+
+	info, exists := s.Template("drake")
+	require.True(t, exists)
+	assert.Equal(t, imageChecksum, info.SHA256)
+
+	// The images are trusted as they are, which health reports without degrading
+	health := s.Health()
+	assert.Equal(t, service.HealthOK, health.Status)
+	assert.Equal(t, []string{"change-my-mind", "drake"}, health.Unverified)
+
+	// The next manifest write records the checksums
+	name := "Drake Hotline Bling"
+	_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{Name: &name})
+	require.NoError(t, err)
+	assert.Empty(t, s.Health().Unverified)
+
+	templates, err := service.LoadTemplates(s.Config.TemplateDir)
+	require.NoError(t, err)
+	assert.Equal(t, imageChecksum, templates["change-my-mind"].SHA256)
+}
+
+func TestChecksum_MismatchQuarantines(t *testing.T) {
+	s := setupIntegrityService(t, checksumManifest(badChecksum))
+
+	_, exists := s.Template("change-my-mind")
+	assert.False(t, exists)
+
+	resp, err := s.ListTemplates(context.Background(), &pb.ListTemplatesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Templates, 1)
+	assert.Equal(t, "drake", resp.Templates[0].Id)
+
+	quarantined := s.QuarantinedTemplates()
+	require.Len(t, quarantined, 1)
+	assert.Equal(t, "change-my-mind", quarantined[0].ID)
+	assert.Contains(t, quarantined[0].Reason, badChecksum)
+
+	health := s.Health()
+	assert.Equal(t, service.HealthDegraded, health.Status)
+	assert.Equal(t, 1, health.Templates)
+}
+
+func TestChecksum_TruncatedImage(t *testing.T) {
+	s := setupIntegrityService(t, checksumManifest(imageChecksum))
+
+	err := os.WriteFile(filepath.Join(s.Config.TemplateDir, "change-my-mind.jpg"), []byte("ima"), 0644)
+	require.NoError(t, err)
+	require.NoError(t, s.ReloadTemplates())
+
+	_, exists := s.Template("change-my-mind")
+	assert.False(t, exists)
+	assert.Len(t, s.QuarantinedTemplates(), 1)
+
+	// Restoring the image brings the template back on the next reload
+	err = os.WriteFile(filepath.Join(s.Config.TemplateDir, "change-my-mind.jpg"), []byte("image"), 0644)
+	require.NoError(t, err)
+	require.NoError(t, s.ReloadTemplates())
+
+	_, exists = s.Template("change-my-mind")
+	assert.True(t, exists)
+	assert.Empty(t, s.QuarantinedTemplates())
+}
+
+func TestChecksum_InvalidFormat(t *testing.T) {
+	_, err := service.LoadTemplates(writeManifestDir(t, checksumManifest("not-a-checksum"), "drake.jpg", "change-my-mind.jpg"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sha256 must be 64 hexadecimal characters")
+}
+
+func TestChecksum_StoreKeepsQuarantinedTemplates(t *testing.T) {
+	s := setupIntegrityService(t, checksumManifest(badChecksum))
+
+	// Changing another template leaves the quarantined entry in the manifest
+	name := "Drake Hotline Bling"
+	_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{Name: &name})
+	require.NoError(t, err)
+
+	templates, err := service.LoadTemplates(s.Config.TemplateDir)
+	require.NoError(t, err)
+	require.Contains(t, templates, "change-my-mind")
+	assert.Equal(t, badChecksum, templates["change-my-mind"].SHA256)
+	assert.True(t, templates["change-my-mind"].Quarantined())
+
+	// Metadata changes cannot bring it back, and its id stays taken
+	_, err = s.UpdateTemplate("change-my-mind", &service.TemplateUpdate{Name: &name})
+	assert.ErrorIs(t, err, service.ErrTemplateNotFound)
+
+	_, err = s.CreateTemplate(&service.TemplateInfo{ID: "change-my-mind", Name: "Change", TextFieldCount: 1}, nil)
+	assert.ErrorIs(t, err, service.ErrTemplateExists)
+}
+
+func TestChecksum_ReplaceImageRestores(t *testing.T) {
+	s := setupIntegrityService(t, checksumManifest(badChecksum))
+	h := handler.NewAdminHandler(s, testAdminToken)
+
+	img := testImage(t, "jpeg", 120, 120)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodPut, "/admin/templates/change-my-mind/image", string(img)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	info, exists := s.Template("change-my-mind")
+	require.True(t, exists)
+	sum := sha256.Sum256(img)
+	assert.Equal(t, hex.EncodeToString(sum[:]), info.SHA256)
+	assert.Empty(t, s.QuarantinedTemplates())
+
+	templates, err := service.LoadTemplates(s.Config.TemplateDir)
+	require.NoError(t, err)
+	assert.False(t, templates["change-my-mind"].Quarantined())
+}
+
+func TestChecksum_DeleteQuarantined(t *testing.T) {
+	s := setupIntegrityService(t, checksumManifest(badChecksum))
+
+	require.NoError(t, s.DeleteTemplate("change-my-mind"))
+	assert.Empty(t, s.QuarantinedTemplates())
+
+	templates, err := service.LoadTemplates(s.Config.TemplateDir)
+	require.NoError(t, err)
+	assert.NotContains(t, templates, "change-my-mind")
+}
+
+func TestChecksum_Lint(t *testing.T) {
+	dir := writeManifestDir(t, checksumManifest(badChecksum), "drake.jpg", "change-my-mind.jpg")
+
+	report := service.LintTemplates(dir, 1024)
+	messages := lintMessages(report)
+	require.NotEmpty(t, messages["change-my-mind/error"])
+	assert.Contains(t, messages["change-my-mind/error"][0], badChecksum)
+}
+
+func TestChecksum_LintMissing(t *testing.T) {
+	report := service.LintTemplates(writeManifestDir(t, twoTemplateManifest, "drake.jpg", "change-my-mind.jpg"), 1024)
+	messages := lintMessages(report)
+
+	// The placeholder images do not decode either, which is reported separately
+	assert.Contains(t, messages["drake/error"], "sha256 is missing, image drake.jpg has sha256 "+imageChecksum)
+	assert.Contains(t, messages["change-my-mind/error"], "sha256 is missing, image change-my-mind.jpg has sha256 "+imageChecksum)
+}
+
+func TestHealthHandler(t *testing.T) {
+	s := setupIntegrityService(t, checksumManifest(badChecksum))
+
+	rec := httptest.NewRecorder()
+	handler.NewHealthHandler(s).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var report service.HealthReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, service.HealthDegraded, report.Status)
+	assert.Equal(t, 1, report.Templates)
+	require.Len(t, report.Quarantined, 1)
+	assert.Equal(t, "change-my-mind.jpg", report.Quarantined[0].Filename)
+}
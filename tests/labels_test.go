@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLabelService creates a service whose templates carry categories, tags and aliases
+func setupLabelService() *service.MemeService {
+	return &service.MemeService{
+		Config: &config.Config{},
+		Templates: map[string]*service.TemplateInfo{
+			"drake": {
+				Name: "Drake Hotline Bling", TextFieldCount: 2, Category: "classic", Filename: "drake.jpg",
+				Categories: []string{"reaction"}, Tags: []string{"comparison"}, Aliases: []string{"hotline-bling"},
+			},
+			"change-my-mind": {
+				Name: "Change My Mind", TextFieldCount: 1, Category: "debate", Filename: "change-my-mind.jpg",
+				Tags: []string{"opinion"},
+			},
+		},
+	}
+}
+
+func TestResolveTemplate(t *testing.T) {
+	s := setupLabelService()
+
+	info, id, exists := s.ResolveTemplate("hotline-bling")
+	require.True(t, exists)
+	assert.Equal(t, "drake", id)
+	assert.Equal(t, "Drake Hotline Bling", info.Name)
+
+	_, id, exists = s.ResolveTemplate("drake")
+	require.True(t, exists)
+	assert.Equal(t, "drake", id)
+
+	_, _, exists = s.ResolveTemplate("hotline")
+	assert.False(t, exists)
+}
+
+func TestMemeService_GenerateMeme_Alias(t *testing.T) {
+	s := setupLabelService()
+	s.Config.TemplateDir = t.TempDir()
+
+	// The image is missing, so an alias that resolves fails later than an unknown id does
+	resp, err := s.GenerateMeme(context.Background(), &pb.GenerateMemeRequest{TemplateId: "hotline-bling", TopText: "Top"})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "Failed to generate meme")
+
+	resp, err = s.GenerateMeme(context.Background(), &pb.GenerateMemeRequest{TemplateId: "hotline", TopText: "Top"})
+	require.NoError(t, err)
+	assert.Equal(t, "Template 'hotline' not found", resp.Error)
+}
+
+func TestMemeService_ListTemplates_Labels(t *testing.T) {
+	s := setupLabelService()
+
+	tests := []struct {
+		name     string
+		category string
+		want     []string
+	}{
+		{name: "Primary category", category: "classic", want: []string{"drake"}},
+		{name: "Additional category", category: "reaction", want: []string{"drake"}},
+		{name: "Tag", category: "opinion", want: []string{"change-my-mind"}},
+		{name: "Case-insensitive", category: "Comparison", want: []string{"drake"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.ListTemplates(context.Background(), &pb.ListTemplatesRequest{Category: tt.category})
+			require.NoError(t, err)
+
+			var ids []string
+			for _, template := range resp.Templates {
+				ids = append(ids, template.Id)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestSearchTemplates_Labels(t *testing.T) {
+	s := setupLabelService()
+
+	page, err := s.SearchTemplates(&service.TemplateQuery{Tag: "comparison"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"drake"}, templateIDs(page.Templates))
+	assert.Equal(t, []string{"classic", "reaction"}, page.Templates[0].AllCategories())
+
+	page, err = s.SearchTemplates(&service.TemplateQuery{Text: "hotline-bling"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"drake"}, templateIDs(page.Templates))
+}
+
+func TestLoadTemplates_Labels(t *testing.T) {
+	dir := writeManifestDir(t, `{"templates": [
+		{"id": "drake", "name": "Drake", "categories": ["classic", "reaction"], "aliases": ["hotline-bling"],
+		 "filename": "drake.jpg", "text_field_count": 2}
+	]}`, "drake.jpg")
+
+	templates, err := service.LoadTemplates(dir)
+	require.NoError(t, err)
+
+	// Without a category the first additional category becomes the primary one
+	assert.Equal(t, "classic", templates["drake"].Category)
+	assert.Equal(t, []string{"hotline-bling"}, templates["drake"].Aliases)
+}
+
+func TestLoadTemplates_AliasConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name: "Alias shared by two templates",
+			manifest: `{"templates": [
+				{"id": "drake", "name": "Drake", "filename": "img.jpg", "text_field_count": 2, "aliases": ["classic-meme"]},
+				{"id": "two-buttons", "name": "Two Buttons", "filename": "img.jpg", "text_field_count": 2, "aliases": ["classic-meme"]}
+			]}`,
+			wantErr: "alias 'classic-meme' is already used by template 'drake'",
+		},
+		{
+			name: "Alias equal to another id",
+			manifest: `{"templates": [
+				{"id": "drake", "name": "Drake", "filename": "img.jpg", "text_field_count": 2, "aliases": ["two-buttons"]},
+				{"id": "two-buttons", "name": "Two Buttons", "filename": "img.jpg", "text_field_count": 2}
+			]}`,
+			wantErr: "alias 'two-buttons' is the id of another template",
+		},
+		{
+			name: "Malformed alias",
+			manifest: `{"templates": [
+				{"id": "drake", "name": "Drake", "filename": "img.jpg", "text_field_count": 2, "aliases": ["Hotline Bling"]}
+			]}`,
+			wantErr: "alias 'Hotline Bling' must contain only lowercase letters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.LoadTemplates(writeManifestDir(t, tt.manifest, "img.jpg"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestAdmin_UpdateTemplate_AliasConflict(t *testing.T) {
+	s, h := setupAdminTest(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, uploadRequest(t,
+		`{"id": "success-kid", "name": "Success Kid", "text_field_count": 2, "aliases": ["win"]}`,
+		testImage(t, "jpeg", 200, 150)))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, adminRequest(http.MethodPatch, "/admin/templates/drake", `{"aliases": ["win"]}`))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "alias 'win' is already used")
+
+	info, _ := s.Template("drake")
+	assert.Empty(t, info.Aliases)
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/assets"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+// Every glyph of basicfont.Face7x13 advances 7 pixels, so widths are easy to predict
+const glyphWidth = 7
+
+// lineTexts returns the text of every line in a layout
+func lineTexts(layout *service.TextLayout) []string {
+	texts := make([]string, 0, len(layout.Lines))
+	for _, line := range layout.Lines {
+		texts = append(texts, line.Text)
+	}
+	return texts
+}
+
+func TestLayoutText_Wrapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxWidth int
+		want     []string
+	}{
+		{"Fits", "hello world", 11 * glyphWidth, []string{"hello world"}},
+		{"Word boundaries", "hello big world", 9 * glyphWidth, []string{"hello big", "world"}},
+		{"Collapses spaces", "hello   world", 5 * glyphWidth, []string{"hello", "world"}},
+		{"Long word", "abcdefghij", 4 * glyphWidth, []string{"abcd", "efgh", "ij"}},
+		{"Long word after text", "hi abcdefgh", 5 * glyphWidth, []string{"hi", "abcde", "fgh"}},
+		{"Multi-byte runes", "ééééé ààààà", 5 * glyphWidth, []string{"ééééé", "ààààà"}},
+		{"Newlines", "top\n\nbottom", 20 * glyphWidth, []string{"top", "", "bottom"}},
+		{"Narrower than a glyph", "ab", 3, []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := service.LayoutText(basicfont.Face7x13, tt.text, service.LayoutOptions{MaxWidth: tt.maxWidth, LineHeight: 20})
+			assert.Equal(t, tt.want, lineTexts(layout))
+			assert.False(t, layout.Truncated)
+		})
+	}
+}
+
+func TestLayoutText_LineBoxes(t *testing.T) {
+	opts := service.LayoutOptions{MaxWidth: 100, LineHeight: 20}
+
+	layout := service.LayoutText(basicfont.Face7x13, "hello world again", opts)
+	require.Equal(t, []string{"hello world", "again"}, lineTexts(layout))
+	assert.Equal(t, 11*glyphWidth, layout.Width)
+	assert.Equal(t, 40, layout.Height)
+
+	// Centered by default, one line height apart, with the baseline inside each box
+	assert.Equal(t, image.Rect(11, 0, 88, 20), layout.Lines[0].Bounds)
+	assert.Equal(t, image.Rect(32, 20, 67, 40), layout.Lines[1].Bounds)
+	for _, line := range layout.Lines {
+		assert.Greater(t, line.Baseline, line.Bounds.Min.Y)
+		assert.Less(t, line.Baseline, line.Bounds.Max.Y)
+	}
+	assert.Equal(t, layout.Lines[0].Baseline+20, layout.Lines[1].Baseline)
+
+	opts.Align = service.AlignLeft
+	layout = service.LayoutText(basicfont.Face7x13, "hello world again", opts)
+	assert.Equal(t, 0, layout.Lines[1].Bounds.Min.X)
+
+	opts.Align = service.AlignRight
+	layout = service.LayoutText(basicfont.Face7x13, "hello world again", opts)
+	assert.Equal(t, 100, layout.Lines[1].Bounds.Max.X)
+}
+
+func TestLayoutText_MaxLines(t *testing.T) {
+	layout := service.LayoutText(basicfont.Face7x13, "one two three four", service.LayoutOptions{MaxWidth: 5 * glyphWidth, LineHeight: 20, MaxLines: 2})
+
+	assert.Equal(t, []string{"one", "two"}, lineTexts(layout))
+	assert.True(t, layout.Truncated)
+	assert.Equal(t, 40, layout.Height)
+}
+
+func TestLayoutText_Ellipsis(t *testing.T) {
+	opts := service.LayoutOptions{MaxWidth: 5 * glyphWidth, LineHeight: 20, MaxLines: 2, Ellipsis: "..."}
+
+	// The last kept line is shortened until the ellipsis fits
+	layout := service.LayoutText(basicfont.Face7x13, "one two three four", opts)
+	assert.Equal(t, []string{"one", "tw..."}, lineTexts(layout))
+	assert.True(t, layout.Truncated)
+
+	// Nothing is added when every line fits
+	layout = service.LayoutText(basicfont.Face7x13, "one two", opts)
+	assert.Equal(t, []string{"one", "two"}, lineTexts(layout))
+}
+
+func TestLayoutText_MeasuresRealAdvances(t *testing.T) {
+	f, err := truetype.Parse(assets.Font())
+	require.NoError(t, err)
+	face := truetype.NewFace(f, &truetype.Options{Size: 48, DPI: 72, Hinting: font.HintingFull})
+
+	// Narrow and wide glyphs have very different advances, which a per-byte estimate misses
+	text := strings.Repeat("il ", 20) + strings.Repeat("WM ", 20)
+	layout := service.LayoutText(face, text, service.LayoutOptions{MaxWidth: 300, LineHeight: 60})
+
+	for _, line := range layout.Lines {
+		assert.LessOrEqual(t, font.MeasureString(face, line.Text).Ceil(), 300, line.Text)
+	}
+	assert.Less(t, len(layout.Lines[0].Text), 60)
+	assert.Greater(t, len(layout.Lines[0].Text), len(layout.Lines[len(layout.Lines)-1].Text))
+}
+
+func TestRenderMeme_CaptionsStayInsideImage(t *testing.T) {
+	s := setupVersionService(t)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.TemplateDir, "drake.jpg"), testImage(t, "jpeg", 400, 400), 0644))
+	require.NoError(t, s.ReloadTemplates())
+
+	resp, err := s.GenerateMeme(context.Background(), &pb.GenerateMemeRequest{
+		TemplateId: "drake",
+		TopText:    "a caption long enough to need several lines at this width",
+		BottomText: "Ünïcödé çäptïöns ärë wrappëd by glyph width töö",
+	})
+	require.NoError(t, err)
+	img := decodeMeme(t, resp)
+
+	// Wrapped lines keep clear of the left and right edges
+	for _, column := range []int{0, 1, 398, 399} {
+		strip := crop(img, image.Rect(column, 0, column+1, 400))
+		assert.Zero(t, countPixels(strip, isWhite), "text reaches column %d", column)
+		assert.Zero(t, countPixels(strip, isBlack), "outline reaches column %d", column)
+	}
+
+	// Both captions are drawn: top and bottom quarters hold text, the middle does not
+	assert.Positive(t, countPixels(crop(img, image.Rect(0, 0, 400, 100)), isWhite))
+	assert.Positive(t, countPixels(crop(img, image.Rect(0, 300, 400, 400)), isWhite))
+	assert.Zero(t, countPixels(crop(img, image.Rect(0, 180, 400, 220)), isWhite))
+}
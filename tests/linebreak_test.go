@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestLayoutText_CJKLineBreaking(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxWidth int
+		want     []string
+	}{
+		{"Between Japanese characters", "今日はいい天気ですね", 4 * glyphWidth, []string{"今日はい", "い天気で", "すね"}},
+		{"Between Chinese characters", "我们今天去看电影吧。", 3 * glyphWidth, []string{"我们今", "天去看", "电影", "吧。"}},
+		{"Punctuation stays with the text before it", "今日は、晴れ。", 3 * glyphWidth, []string{"今日", "は、晴", "れ。"}},
+		{"Brackets stay with the text inside", "彼は「はい」と言った", 4 * glyphWidth, []string{"彼は「は", "い」と", "言った"}},
+		{"Small kana do not start a line", "きょうはちょっと", 5 * glyphWidth, []string{"きょうは", "ちょっと"}},
+		{"Prolonged sound marks do not start a line", "コーヒーとケーキ", 3 * glyphWidth, []string{"コー", "ヒーと", "ケーキ"}},
+		{"Latin words in CJK text", "Hello 世界 world", 8 * glyphWidth, []string{"Hello 世界", "world"}},
+		{"Latin words stay whole", "meme世界generator", 9 * glyphWidth, []string{"meme世界", "generator"}},
+		{"Fits", "日本語", 3 * glyphWidth, []string{"日本語"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := service.LayoutText(basicfont.Face7x13, tt.text, service.LayoutOptions{MaxWidth: tt.maxWidth, LineHeight: 20})
+			assert.Equal(t, tt.want, lineTexts(layout))
+		})
+	}
+}
+
+func TestLayoutText_KinsokuInLongWords(t *testing.T) {
+	// Splitting a word wider than the line moves the character before a comma along with it
+	layout := service.LayoutText(basicfont.Face7x13, "abcdefg,", service.LayoutOptions{MaxWidth: 7 * glyphWidth, LineHeight: 20})
+	assert.Equal(t, []string{"abcdef", "g,"}, lineTexts(layout))
+
+	// A word with nowhere the rules allow a break is still split to fit
+	layout = service.LayoutText(basicfont.Face7x13, "」」」」", service.LayoutOptions{MaxWidth: 2 * glyphWidth, LineHeight: 20})
+	assert.Equal(t, []string{"」」", "」」"}, lineTexts(layout))
+}
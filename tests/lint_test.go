@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintMessages groups the messages of a lint report by template and severity
+func lintMessages(report *service.LintReport) map[string][]string {
+	messages := make(map[string][]string)
+	for _, issue := range report.Issues {
+		key := issue.Template + "/" + issue.Severity
+		messages[key] = append(messages[key], issue.Message)
+	}
+	return messages
+}
+
+// recordChecksums adds the sha256 of every image that exists to the manifest in dir
+func recordChecksums(t *testing.T, dir string) {
+	path := filepath.Join(dir, service.ManifestFilename)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var manifest struct {
+		Templates []map[string]interface{} `json:"templates"`
+	}
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	for _, entry := range manifest.Templates {
+		image, err := os.ReadFile(filepath.Join(dir, entry["filename"].(string)))
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(image)
+		entry["sha256"] = hex.EncodeToString(sum[:])
+	}
+
+	data, err = json.Marshal(manifest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func TestLintTemplates_DefaultPack(t *testing.T) {
+	report := service.LintTemplates(filepath.Join("..", "assets", "templates"), 4096)
+
+	assert.Equal(t, 4, report.Templates)
+	assert.Empty(t, report.Issues)
+}
+
+func TestLintTemplates_Problems(t *testing.T) {
+	dir := writeManifestDir(t, `{"templates": [
+		{"id": "good", "name": "Good", "category": "test", "filename": "good.jpg", "text_field_count": 2,
+		 "text_regions": [
+			{"name": "top", "x": 0, "y": 0, "width": 200, "height": 50},
+			{"name": "bottom", "x": 0, "y": 150, "width": 200, "height": 50}
+		 ]},
+		{"id": "outside", "name": "Outside", "category": "test", "filename": "outside.jpg", "text_field_count": 1,
+		 "text_regions": [{"name": "caption", "x": 150, "y": 0, "width": 100, "height": 50}]},
+		{"id": "mismatch", "name": "Mismatch", "category": "test", "filename": "mismatch.jpg", "text_field_count": 3,
+		 "text_regions": [{"name": "caption", "x": 0, "y": 0, "width": 100, "height": 50}]},
+		{"id": "rotated", "name": "Rotated", "category": "test", "filename": "rotated.jpg", "text_field_count": 1,
+		 "text_regions": [{"name": "corner", "x": 0, "y": 0, "width": 100, "height": 50, "rotation": 30}]},
+		{"id": "corrupt", "name": "Corrupt", "category": "test", "filename": "corrupt.jpg", "text_field_count": 1},
+		{"id": "tiny", "name": "Tiny", "category": "test", "filename": "tiny.png", "text_field_count": 1},
+		{"id": "missing", "name": "Missing", "category": "test", "filename": "missing.jpg", "text_field_count": 1}
+	]}`, "corrupt.jpg")
+
+	for name, data := range map[string][]byte{
+		"good.jpg":     testImage(t, "jpeg", 200, 200),
+		"outside.jpg":  testImage(t, "jpeg", 200, 200),
+		"mismatch.jpg": testImage(t, "jpeg", 200, 200),
+		"rotated.jpg":  testImage(t, "jpeg", 200, 200),
+		"tiny.png":     testImage(t, "png", 32, 32),
+		"orphan.png":   testImage(t, "png", 100, 100),
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0644))
+	}
+	recordChecksums(t, dir)
+
+	report := service.LintTemplates(dir, 1024)
+	messages := lintMessages(report)
+
+	assert.NotContains(t, messages, "good/error")
+	assert.NotContains(t, messages, "good/warning")
+	assert.Len(t, messages["outside/error"], 1)
+	assert.Contains(t, messages["outside/error"][0], "text region 'caption'")
+	assert.Equal(t, []string{"text_field_count is 3 but 1 text regions are declared"}, messages["mismatch/error"])
+	assert.Len(t, messages["rotated/warning"], 1)
+	assert.Len(t, messages["corrupt/error"], 1)
+	assert.Contains(t, messages["corrupt/error"][0], "could not be decoded")
+	assert.Equal(t, []string{"image is 32x32, minimum is 64x64"}, messages["tiny/error"])
+	assert.Equal(t, []string{"image missing.jpg not found"}, messages["missing/error"])
+	assert.Equal(t, []string{"image orphan.png is not used by any template"}, messages["/warning"])
+
+	assert.Equal(t, 5, report.Errors)
+	assert.Equal(t, 2, report.Warnings)
+}
+
+func TestLintTemplates_InvalidManifest(t *testing.T) {
+	report := service.LintTemplates(writeManifestDir(t, `{"templates": [`), 1024)
+	assert.Equal(t, 1, report.Errors)
+
+	report = service.LintTemplates(filepath.Join(t.TempDir(), "missing"), 1024)
+	require.Len(t, report.Issues, 1)
+	assert.Contains(t, report.Issues[0].Message, "not found")
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeManifestDir creates a template directory with the given manifest and image files
+func writeManifestDir(t *testing.T, manifest string, images ...string) string {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, service.ManifestFilename), []byte(manifest), 0644)
+	require.NoError(t, err)
+
+	for _, image := range images {
+		err := os.WriteFile(filepath.Join(dir, image), []byte("image"), 0644)
+		require.NoError(t, err)
+	}
+
+	return dir
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := writeManifestDir(t, `{
+		"templates": [
+			{"id": "drake", "name": "Drake Hotline Bling", "category": "classic", "filename": "drake.jpg", "text_field_count": 2},
+			{"id": "change-my-mind", "name": "Change My Mind", "category": "debate", "filename": "change-my-mind.jpg", "text_field_count": 1}
+		]
+	}`, "drake.jpg", "change-my-mind.jpg")
+
+	templates, err := service.LoadTemplates(dir)
+	require.NoError(t, err)
+
+	assert.Len(t, templates, 2)
+	assert.Equal(t, "Drake Hotline Bling", templates["drake"].Name)
+	assert.Equal(t, int32(2), templates["drake"].TextFieldCount)
+	assert.Equal(t, "debate", templates["change-my-mind"].Category)
+	assert.Empty(t, templates["drake"].TextRegions)
+}
+
+func TestLoadTemplates_TextRegions(t *testing.T) {
+	dir := writeManifestDir(t, `{
+		"templates": [
+			{"id": "two-buttons", "name": "Two Buttons", "filename": "two-buttons.jpg", "text_field_count": 2,
+			 "text_regions": [
+				{"name": "left-button", "x": 40, "y": 80, "width": 200, "height": 90, "rotation": -12},
+				{"name": "right-button", "x": 280, "y": 40, "width": 220, "height": 90, "align": "right", "max_lines": 2}
+			 ]}
+		]
+	}`, "two-buttons.jpg")
+
+	templates, err := service.LoadTemplates(dir)
+	require.NoError(t, err)
+
+	regions := templates["two-buttons"].TextRegions
+	require.Len(t, regions, 2)
+	assert.Equal(t, "left-button", regions[0].Name)
+	assert.Equal(t, -12.0, regions[0].Rotation)
+	assert.Equal(t, 280, regions[1].Rect().Min.X)
+	assert.Equal(t, 130, regions[1].Rect().Max.Y)
+	assert.Equal(t, service.AlignRight, regions[1].Align)
+	assert.Equal(t, 2, regions[1].MaxLines)
+}
+
+func TestLoadTemplates_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		images   []string
+		wantErr  string
+	}{
+		{
+			name: "Duplicate id",
+			manifest: `{"templates": [
+				{"id": "drake", "name": "Drake", "filename": "drake.jpg", "text_field_count": 2},
+				{"id": "drake", "name": "Drake Again", "filename": "drake.jpg", "text_field_count": 2}
+			]}`,
+			images:  []string{"drake.jpg"},
+			wantErr: "template 'drake': duplicate id",
+		},
+		{
+			name: "Missing image",
+			manifest: `{"templates": [
+				{"id": "drake", "name": "Drake", "filename": "drake.jpg", "text_field_count": 2}
+			]}`,
+			wantErr: "image drake.jpg not found",
+		},
+		{
+			name: "Bad text field count",
+			manifest: `{"templates": [
+				{"id": "drake", "name": "Drake", "filename": "drake.jpg", "text_field_count": 0}
+			]}`,
+			images:  []string{"drake.jpg"},
+			wantErr: "text_field_count must be between 1 and 10",
+		},
+		{
+			name: "Invalid id",
+			manifest: `{"templates": [
+				{"id": "Drake Meme", "name": "Drake", "filename": "drake.jpg", "text_field_count": 2}
+			]}`,
+			images:  []string{"drake.jpg"},
+			wantErr: "id must contain only lowercase letters",
+		},
+		{
+			name: "Filename with directory",
+			manifest: `{"templates": [
+				{"id": "drake", "name": "Drake", "filename": "../drake.jpg", "text_field_count": 2}
+			]}`,
+			wantErr: "must not contain a directory",
+		},
+		{
+			name: "Invalid text region",
+			manifest: `{"templates": [
+				{"id": "drake", "name": "Drake", "filename": "drake.jpg", "text_field_count": 1,
+				 "text_regions": [{"name": "top", "x": 0, "y": 0, "width": 0, "height": 100, "align": "middle"}]}
+			]}`,
+			images:  []string{"drake.jpg"},
+			wantErr: "text region 'top': width and height must be positive",
+		},
+		{
+			name:     "Unknown field",
+			manifest: `{"templates": [{"id": "drake", "title": "Drake"}]}`,
+			wantErr:  `unknown field "title"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeManifestDir(t, tt.manifest, tt.images...)
+
+			templates, err := service.LoadTemplates(dir)
+			require.Error(t, err)
+			assert.Nil(t, templates)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadTemplates_MissingManifest(t *testing.T) {
+	_, err := service.LoadTemplates(t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to open template manifest")
+}
+
+func TestLoadTemplates_DefaultManifest(t *testing.T) {
+	// The default pack embedded in the binary must load as a template directory
+	templates, err := service.LoadTemplates(filepath.Join("..", "assets", "templates"))
+	require.NoError(t, err)
+	assert.Len(t, templates, 4)
+	assert.Contains(t, templates, "drake")
+	assert.Contains(t, templates, "distracted-boyfriend")
+	assert.Contains(t, templates, "two-buttons")
+	assert.Contains(t, templates, "change-my-mind")
+	assert.Len(t, templates["drake"].TextRegions, 2)
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/assets"
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateStyled renders captions on the 400x400 drake template of a fit test service
+func generateStyled(t *testing.T, captions ...service.Caption) image.Image {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+
+	meme, err := s.Generate(context.Background(), &service.MemeRequest{TemplateID: "drake", Captions: captions})
+	require.NoError(t, err)
+
+	img, _, err := image.Decode(bytes.NewReader(meme.ImageData))
+	require.NoError(t, err)
+	return img
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestGenerate_UnstyledMatchesGenerateMeme(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+
+	resp, err := s.GenerateMeme(context.Background(), &pb.GenerateMemeRequest{TemplateId: "drake", TopText: "Top", BottomText: "Bottom"})
+	require.NoError(t, err)
+	require.Empty(t, resp.Error)
+
+	meme, err := s.Generate(context.Background(), &service.MemeRequest{
+		TemplateID: "drake",
+		Captions:   []service.Caption{{Text: "Top"}, {Text: "Bottom"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, resp.ImageData, base64.StdEncoding.EncodeToString(meme.ImageData))
+	assert.Equal(t, "image/jpeg", meme.MimeType)
+	assert.Equal(t, "drake", meme.TemplateID)
+	assert.Len(t, meme.TemplateVersion, 16)
+}
+
+func TestGenerate_CaptionStyles(t *testing.T) {
+	img := generateStyled(t,
+		service.Caption{Text: "Top", Style: &service.CaptionStyle{TextStyle: service.TextStyle{Color: "#00ff00", StrokeColor: "#0000ff"}}},
+		service.Caption{Text: "Bottom"},
+	)
+	top := crop(img, image.Rect(0, 0, 400, 200))
+	bottom := crop(img, image.Rect(0, 200, 400, 400))
+
+	// Only the styled caption changes
+	assert.Positive(t, countPixels(top, isGreen))
+	assert.Positive(t, countPixels(top, isBlue))
+	assert.Zero(t, countPixels(top, isWhite))
+	assert.Positive(t, countPixels(bottom, isWhite))
+	assert.Positive(t, countPixels(bottom, isBlack))
+	assert.Zero(t, countPixels(bottom, isGreen))
+}
+
+func TestGenerate_CaptionSizeAndAlignment(t *testing.T) {
+	small := matchBounds(generateStyled(t, service.Caption{Text: "Hi", Style: &service.CaptionStyle{Size: floatPtr(14)}}), isWhite)
+	large := matchBounds(generateStyled(t, service.Caption{Text: "Hi", Style: &service.CaptionStyle{Size: floatPtr(60)}}), isWhite)
+	assert.Greater(t, large.Dy(), 3*small.Dy())
+
+	left := matchBounds(generateStyled(t, service.Caption{Text: "Hi", Style: &service.CaptionStyle{TextStyle: service.TextStyle{Align: service.AlignLeft}}}), isWhite)
+	right := matchBounds(generateStyled(t, service.Caption{Text: "Hi", Style: &service.CaptionStyle{TextStyle: service.TextStyle{Align: service.AlignRight}}}), isWhite)
+	assert.Less(t, left.Max.X, 200)
+	assert.Greater(t, right.Min.X, 200)
+}
+
+func TestGenerate_CaptionFont(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.TemplateDir, "other.ttf"), assets.Font(), 0644))
+
+	_, err := s.Generate(context.Background(), &service.MemeRequest{
+		TemplateID: "drake",
+		Captions:   []service.Caption{{Text: "Top", Style: &service.CaptionStyle{TextStyle: service.TextStyle{Font: "other.ttf", Case: service.CaseUpper}}}},
+	})
+	assert.NoError(t, err)
+}
+
+func TestGenerate_CaptionValidation(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowEllipsis)
+
+	tests := []struct {
+		name  string
+		style service.CaptionStyle
+		want  string
+	}{
+		{"Bad colour", service.CaptionStyle{TextStyle: service.TextStyle{Color: "red"}}, `captions[1].style: colour "red" must start with #`},
+		{"Bad stroke colour", service.CaptionStyle{TextStyle: service.TextStyle{StrokeColor: "#12345"}}, `captions[1].style: colour "#12345" must be #rgb, #rrggbb or #rrggbbaa`},
+		{"Wide stroke", service.CaptionStyle{TextStyle: service.TextStyle{StrokeWidth: intPtr(50)}}, "captions[1].style: stroke_width must be between 0 and 20"},
+		{"Unknown align", service.CaptionStyle{TextStyle: service.TextStyle{Align: "justify"}}, "captions[1].style: align must be one of left, center, right, start or end"},
+		{"Unknown case", service.CaptionStyle{TextStyle: service.TextStyle{Case: "lower"}}, "captions[1].style: case must be upper or as-typed"},
+		{"Unknown font", service.CaptionStyle{TextStyle: service.TextStyle{Font: "comic.ttf"}}, "captions[1].style: unknown font comic.ttf"},
+		{"Font path", service.CaptionStyle{TextStyle: service.TextStyle{Font: "../comic.ttf"}}, "captions[1].style: font ../comic.ttf must not contain a directory"},
+		{"Zero size", service.CaptionStyle{Size: floatPtr(0)}, "captions[1].style: size must be greater than 0 and at most 200"},
+		{"Huge size", service.CaptionStyle{Size: floatPtr(500)}, "captions[1].style: size must be greater than 0 and at most 200"},
+		{"Wide blur", service.CaptionStyle{TextStyle: service.TextStyle{Shadow: &service.TextShadow{Blur: 60}}}, "captions[1].style: shadow.blur must be between 0 and 50"},
+		{"Far shadow", service.CaptionStyle{TextStyle: service.TextStyle{Shadow: &service.TextShadow{OffsetY: -51}}}, "captions[1].style: shadow offsets must be between -50 and 50"},
+		{"Bad shadow colour", service.CaptionStyle{TextStyle: service.TextStyle{Shadow: &service.TextShadow{Color: "black"}}}, `captions[1].style: shadow colour "black" must start with #`},
+		{"Shadow opacity", service.CaptionStyle{TextStyle: service.TextStyle{Shadow: &service.TextShadow{Opacity: floatPtr(1.5)}}}, "captions[1].style: shadow.opacity must be between 0 and 1"},
+		{"Zero glow", service.CaptionStyle{TextStyle: service.TextStyle{Glow: &service.TextGlow{}}}, "captions[1].style: glow.radius must be greater than 0 and at most 50"},
+		{"Glow opacity", service.CaptionStyle{TextStyle: service.TextStyle{Glow: &service.TextGlow{Radius: 4, Opacity: floatPtr(-1)}}}, "captions[1].style: glow.opacity must be between 0 and 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style := tt.style
+			_, err := s.Generate(context.Background(), &service.MemeRequest{
+				TemplateID: "drake",
+				Captions:   []service.Caption{{Text: "Top"}, {Text: "Bottom", Style: &style}},
+			})
+			require.ErrorIs(t, err, service.ErrInvalidCaption)
+			assert.Equal(t, "invalid caption: "+tt.want, err.Error())
+		})
+	}
+}
+
+func TestMemesHandler(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowReject)
+	h := handler.NewMemesHandler(s)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/memes", strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("Styled captions", func(t *testing.T) {
+		rec := post(`{"template_id": "drake", "captions": [{"text": "Top", "style": {"color": "#00ff00", "size": 30}}, {"text": "Bottom"}]}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var meme service.GeneratedMeme
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &meme))
+		assert.Equal(t, "image/jpeg", meme.MimeType)
+		assert.Len(t, meme.TemplateVersion, 16)
+
+		img, _, err := image.Decode(bytes.NewReader(meme.ImageData))
+		require.NoError(t, err)
+		assert.Positive(t, countPixels(img, isGreen))
+	})
+
+	errorTests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"Malformed JSON", `{"template_id": `, http.StatusBadRequest, "invalid meme request"},
+		{"Unknown field", `{"template_id": "drake", "colour": "red"}`, http.StatusBadRequest, "invalid meme request"},
+		{"Missing template", `{"captions": [{"text": "Top"}]}`, http.StatusBadRequest, "template_id is required"},
+		{"Invalid style", `{"template_id": "drake", "captions": [{"text": "Top", "style": {"color": "#ggg"}}]}`, http.StatusBadRequest, "is not hexadecimal"},
+		{"Unknown template", `{"template_id": "nope"}`, http.StatusNotFound, "template not found"},
+		{"Unknown version", `{"template_id": "drake", "template_version": "0000000000000000"}`, http.StatusNotFound, "has no version"},
+		{"Caption too long", `{"template_id": "drake", "captions": [{"text": "` + strings.Repeat(longCaption, 10) + `"}]}`, http.StatusUnprocessableEntity, "caption does not fit"},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(tt.body)
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.want)
+		})
+	}
+}
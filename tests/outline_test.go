@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// matchBounds returns the smallest rectangle holding every pixel whose 8-bit colour satisfies match
+func matchBounds(img image.Image, match func(r, g, b uint32) bool) image.Rectangle {
+	var found image.Rectangle
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if match(r>>8, g>>8, b>>8) {
+				found = found.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return found
+}
+
+func TestOutline_Width(t *testing.T) {
+	img := renderStyled(t, &service.TextStyle{StrokeWidth: intPtr(8)}, "Hi")
+	text := matchBounds(img, isWhite)
+	outline := matchBounds(img, isBlack)
+	require.False(t, text.Empty())
+
+	// The outline grows the text by the stroke width on every side; the top edge of the
+	// image clips it above the top caption
+	assert.InDelta(t, text.Min.X-8, outline.Min.X, 1)
+	assert.InDelta(t, text.Max.X+8, outline.Max.X, 1)
+	assert.InDelta(t, text.Max.Y+8, outline.Max.Y, 1)
+}
+
+func TestOutline_TranslucentStrokeDrawnOnce(t *testing.T) {
+	img := renderStyled(t, &service.TextStyle{StrokeColor: "#00000080", StrokeWidth: intPtr(6)}, "Hi")
+
+	// A half transparent black outline darkens the background by half, without the
+	// overlapping passes that would turn it black
+	darkened := func(r, g, b uint32) bool { return r > 85 && r < 115 && g > 25 && g < 55 && b < 35 }
+	assert.Positive(t, countPixels(img, darkened))
+	assert.Zero(t, countPixels(img, isBlack))
+	assert.Positive(t, countPixels(img, isWhite))
+}
+
+func BenchmarkGenerateMeme_Outline(b *testing.B) {
+	s := setupVersionService(&testing.T{})
+	_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{TextStyle: &service.TextStyle{StrokeWidth: intPtr(service.MaxStrokeWidth)}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := &pb.GenerateMemeRequest{TemplateId: "drake", TopText: "One does not simply", BottomText: "walk into Mordor"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.GenerateMeme(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPreviewTest creates a service with one 800x400 template and a mux serving its previews
+func setupPreviewTest(t *testing.T) (*service.MemeService, http.Handler) {
+	s := setupCatalogService(t, singleTemplateManifest, "drake.jpg")
+	s.Config.ImageQuality = 90
+	s.Config.FontFile = "../testdata/impact.ttf"
+	s.Config.LineSpacing = 1.5
+
+	err := os.WriteFile(filepath.Join(s.Config.TemplateDir, "drake.jpg"), testImage(t, "jpeg", 800, 400), 0644)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /templates/{filename}", handler.NewPreviewHandler(s))
+	return s, mux
+}
+
+// decodePreview decodes a JPEG preview and returns its dimensions
+func decodePreview(t *testing.T, data []byte) image.Rectangle {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img.Bounds()
+}
+
+func TestTemplatePreview_Sizes(t *testing.T) {
+	_, h := setupPreviewTest(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "Default size", query: "", wantWidth: 320, wantHeight: 160},
+		{name: "Small", query: "?size=small", wantWidth: 160, wantHeight: 80},
+		{name: "Large", query: "?size=large", wantWidth: 640, wantHeight: 320},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/templates/drake.jpg"+tt.query, nil))
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+
+			bounds := decodePreview(t, rec.Body.Bytes())
+			assert.Equal(t, tt.wantWidth, bounds.Dx())
+			assert.Equal(t, tt.wantHeight, bounds.Dy())
+		})
+	}
+}
+
+func TestTemplatePreview_Errors(t *testing.T) {
+	_, h := setupPreviewTest(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/templates/missing.jpg", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/templates/drake.jpg?size=huge", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown preview size 'huge'")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/templates/drake.jpg?captions=maybe", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestTemplatePreview_Cache(t *testing.T) {
+	s, _ := setupPreviewTest(t)
+
+	first, err := s.TemplatePreview("drake.jpg", "small", false)
+	require.NoError(t, err)
+
+	second, err := s.TemplatePreview("drake.jpg", "small", false)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	// Replacing the image invalidates the cached thumbnail
+	err = os.WriteFile(filepath.Join(s.Config.TemplateDir, "drake.jpg"), testImage(t, "jpeg", 400, 800), 0644)
+	require.NoError(t, err)
+
+	third, err := s.TemplatePreview("drake.jpg", "small", false)
+	require.NoError(t, err)
+	bounds := decodePreview(t, third)
+	assert.Equal(t, 80, bounds.Dx())
+	assert.Equal(t, 160, bounds.Dy())
+}
+
+func TestTemplatePreview_DeletedTemplate(t *testing.T) {
+	s, _ := setupPreviewTest(t)
+	s.Config.MaxTemplateBytes = 1 << 20
+	s.Config.MaxTemplateDimension = 1024
+
+	_, err := s.TemplatePreview("drake.jpg", "small", false)
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteTemplate("drake"))
+	_, err = s.TemplatePreview("drake.jpg", "small", false)
+	assert.ErrorIs(t, err, service.ErrTemplateNotFound)
+
+	// A new template stored under the same filename gets a fresh thumbnail
+	_, err = s.CreateTemplate(&service.TemplateInfo{ID: "drake", Name: "Drake", TextFieldCount: 2},
+		bytes.NewReader(testImage(t, "jpeg", 400, 800)))
+	require.NoError(t, err)
+
+	data, err := s.TemplatePreview("drake.jpg", "small", false)
+	require.NoError(t, err)
+	bounds := decodePreview(t, data)
+	assert.Equal(t, 80, bounds.Dx())
+	assert.Equal(t, 160, bounds.Dy())
+}
+
+func TestTemplatePreview_Captions(t *testing.T) {
+	if _, err := os.Stat("../testdata/impact.ttf"); os.IsNotExist(err) {
+		t.Skip("Test font file not found, skipping test")
+	}
+
+	s, _ := setupPreviewTest(t)
+
+	plain, err := s.TemplatePreview("drake.jpg", "medium", false)
+	require.NoError(t, err)
+
+	captioned, err := s.TemplatePreview("drake.jpg", "medium", true)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, plain, captioned)
+	assert.Equal(t, decodePreview(t, plain), decodePreview(t, captioned))
+}
+
+func TestTemplatePreview_CaptionsIgnoreRejectPolicy(t *testing.T) {
+	s := setupFitService(t, true, service.OverflowReject)
+	long := strings.Repeat("An example caption far too long for the template ", 20)
+	manifest := `{"templates": [
+		{"id": "drake", "name": "Drake", "category": "classic", "filename": "drake.jpg", "text_field_count": 2,
+		 "example_captions": ["` + long + `", "Short"]}
+	]}`
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.TemplateDir, service.ManifestFilename), []byte(manifest), 0644))
+	require.NoError(t, s.ReloadTemplates())
+
+	// Memes with the caption are rejected, but the preview cuts it short
+	_, err := s.Generate(context.Background(), &service.MemeRequest{TemplateID: "drake", Captions: []service.Caption{{Text: long}}})
+	require.ErrorIs(t, err, service.ErrCaptionTooLong)
+
+	data, err := s.TemplatePreview("drake.jpg", "small", true)
+	require.NoError(t, err)
+	assert.Equal(t, 160, decodePreview(t, data).Dx())
+}
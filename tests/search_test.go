@@ -0,0 +1,252 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// setupSearchService creates a service with a small in-memory catalog
+func setupSearchService() *service.MemeService {
+	added := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return &service.MemeService{
+		Config: &config.Config{},
+		Templates: map[string]*service.TemplateInfo{
+			"drake": {
+				Name: "Drake Hotline Bling", TextFieldCount: 2, Category: "classic", Filename: "drake.jpg",
+				Keywords: []string{"approve", "reject"}, AddedAt: added,
+			},
+			"distracted-boyfriend": {
+				Name: "Distracted Boyfriend", TextFieldCount: 3, Category: "classic", Filename: "distracted-boyfriend.jpg",
+				AddedAt: added.Add(48 * time.Hour),
+			},
+			"two-buttons": {
+				Name: "Two Buttons", TextFieldCount: 3, Category: "classic", Filename: "two-buttons.jpg",
+				Keywords: []string{"choice", "sweating"}, AddedAt: added.Add(24 * time.Hour),
+			},
+			"change-my-mind": {
+				Name: "Change My Mind", TextFieldCount: 1, Category: "debate", Filename: "change-my-mind.jpg",
+				AddedAt: added.Add(72 * time.Hour),
+			},
+		},
+	}
+}
+
+// templateIDs returns the ids of a page of templates in order
+func templateIDs(templates []*service.TemplateInfo) []string {
+	ids := make([]string, 0, len(templates))
+	for _, info := range templates {
+		ids = append(ids, info.ID)
+	}
+	return ids
+}
+
+func TestSearchTemplates_Sorting(t *testing.T) {
+	s := setupSearchService()
+
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{sortBy: "", want: []string{"change-my-mind", "distracted-boyfriend", "drake", "two-buttons"}},
+		{sortBy: service.SortByID, want: []string{"change-my-mind", "distracted-boyfriend", "drake", "two-buttons"}},
+		{sortBy: service.SortByAdded, want: []string{"change-my-mind", "distracted-boyfriend", "two-buttons", "drake"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Sort %q", tt.sortBy), func(t *testing.T) {
+			page, err := s.SearchTemplates(&service.TemplateQuery{SortBy: tt.sortBy})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, templateIDs(page.Templates))
+			assert.Empty(t, page.NextPageToken)
+			assert.Equal(t, 4, page.TotalSize)
+		})
+	}
+}
+
+func TestSearchTemplates_Filtering(t *testing.T) {
+	s := setupSearchService()
+
+	tests := []struct {
+		name  string
+		query service.TemplateQuery
+		want  []string
+	}{
+		{name: "Category", query: service.TemplateQuery{Category: "debate"}, want: []string{"change-my-mind"}},
+		{name: "Name, case-insensitive", query: service.TemplateQuery{Text: "BOYFRIEND"}, want: []string{"distracted-boyfriend"}},
+		{name: "Id", query: service.TemplateQuery{Text: "two-b"}, want: []string{"two-buttons"}},
+		{name: "Keyword", query: service.TemplateQuery{Text: "Approve"}, want: []string{"drake"}},
+		{name: "Category and text", query: service.TemplateQuery{Category: "debate", Text: "drake"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.SearchTemplates(&tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, templateIDs(page.Templates))
+		})
+	}
+}
+
+func TestSearchTemplates_Pagination(t *testing.T) {
+	s := setupSearchService()
+
+	var ids []string
+	query := &service.TemplateQuery{SortBy: service.SortByID, PageSize: 3}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3, "pagination did not terminate")
+
+		page, err := s.SearchTemplates(query)
+		require.NoError(t, err)
+		ids = append(ids, templateIDs(page.Templates)...)
+
+		if page.NextPageToken == "" {
+			break
+		}
+		query.PageToken = page.NextPageToken
+	}
+
+	assert.Equal(t, []string{"change-my-mind", "distracted-boyfriend", "drake", "two-buttons"}, ids)
+}
+
+func TestSearchTemplates_InvalidQuery(t *testing.T) {
+	s := setupSearchService()
+
+	first, err := s.SearchTemplates(&service.TemplateQuery{PageSize: 1})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		query   service.TemplateQuery
+		wantErr string
+	}{
+		{name: "Unknown sort", query: service.TemplateQuery{SortBy: "popularity"}, wantErr: "unknown sort order"},
+		{name: "Negative page size", query: service.TemplateQuery{PageSize: -1}, wantErr: "page size must not be negative"},
+		{name: "Malformed token", query: service.TemplateQuery{PageToken: "!!!"}, wantErr: "malformed page token"},
+		{name: "Token from another query", query: service.TemplateQuery{Category: "classic", PageToken: first.NextPageToken}, wantErr: "does not match the query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.SearchTemplates(&tt.query)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, service.ErrInvalidQuery)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestTemplatesHandler(t *testing.T) {
+	h := handler.NewTemplatesHandler(setupSearchService())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/templates?category=classic&sort=id&page_size=2", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Templates []struct {
+			ID         string `json:"id"`
+			PreviewURL string `json:"preview_url"`
+		} `json:"templates"`
+		NextPageToken string `json:"next_page_token"`
+		TotalSize     int    `json:"total_size"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	require.Len(t, body.Templates, 2)
+	assert.Equal(t, "distracted-boyfriend", body.Templates[0].ID)
+	assert.Equal(t, "/templates/distracted-boyfriend.jpg", body.Templates[0].PreviewURL)
+	assert.NotEmpty(t, body.NextPageToken)
+	assert.Equal(t, 3, body.TotalSize)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/templates?page_size=ten", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMemeService_ListTemplates_StableOrder(t *testing.T) {
+	s := setupSearchService()
+
+	for i := 0; i < 5; i++ {
+		resp, err := s.ListTemplates(context.Background(), &pb.ListTemplatesRequest{})
+		require.NoError(t, err)
+
+		var ids []string
+		for _, template := range resp.Templates {
+			ids = append(ids, template.Id)
+		}
+		assert.Equal(t, []string{"change-my-mind", "distracted-boyfriend", "drake", "two-buttons"}, ids)
+	}
+}
+
+// listTemplatesWithMetadata calls the gRPC ListTemplates handler with the given request
+// metadata and returns the ids it listed along with the response headers
+func listTemplatesWithMetadata(t *testing.T, h *handler.MemeHandler, req *pb.ListTemplatesRequest, md metadata.MD) ([]string, metadata.MD) {
+	stream := &fakeTransportStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	ctx = metadata.NewIncomingContext(ctx, md)
+
+	resp, err := h.ListTemplates(ctx, req)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, template := range resp.Templates {
+		ids = append(ids, template.Id)
+	}
+	return ids, stream.header
+}
+
+func TestHandler_ListTemplatesPaging(t *testing.T) {
+	h := handler.NewMemeHandler(setupSearchService())
+
+	// Without paging metadata the whole catalog comes back at once
+	ids, header := listTemplatesWithMetadata(t, h, &pb.ListTemplatesRequest{}, metadata.MD{})
+	assert.Equal(t, []string{"change-my-mind", "distracted-boyfriend", "drake", "two-buttons"}, ids)
+	assert.Empty(t, header.Get(handler.NextPageTokenKey))
+
+	md := metadata.Pairs(handler.PageSizeKey, "2", handler.SortKey, service.SortByAdded)
+	ids, header = listTemplatesWithMetadata(t, h, &pb.ListTemplatesRequest{}, md)
+	assert.Equal(t, []string{"change-my-mind", "distracted-boyfriend"}, ids)
+	require.Len(t, header.Get(handler.NextPageTokenKey), 1)
+
+	md.Set(handler.PageTokenKey, header.Get(handler.NextPageTokenKey)[0])
+	ids, header = listTemplatesWithMetadata(t, h, &pb.ListTemplatesRequest{}, md)
+	assert.Equal(t, []string{"two-buttons", "drake"}, ids)
+	assert.Empty(t, header.Get(handler.NextPageTokenKey))
+
+	// The text query combines with the category filter of the request
+	ids, _ = listTemplatesWithMetadata(t, h, &pb.ListTemplatesRequest{Category: "classic"}, metadata.Pairs(handler.QueryKey, "BUTTON"))
+	assert.Equal(t, []string{"two-buttons"}, ids)
+	ids, _ = listTemplatesWithMetadata(t, h, &pb.ListTemplatesRequest{Category: "debate"}, metadata.Pairs(handler.QueryKey, "button"))
+	assert.Empty(t, ids)
+}
+
+func TestHandler_ListTemplatesPagingErrors(t *testing.T) {
+	h := handler.NewMemeHandler(setupSearchService())
+
+	for _, md := range []metadata.MD{
+		metadata.Pairs(handler.PageSizeKey, "ten"),
+		metadata.Pairs(handler.SortKey, "random"),
+		metadata.Pairs(handler.PageTokenKey, "not-a-token"),
+	} {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		_, err := h.ListTemplates(ctx, &pb.ListTemplatesRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), md)
+	}
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+// usageByID indexes usage statistics by template id
+func usageByID(stats []service.TemplateUsage) map[string]service.TemplateUsage {
+	byID := make(map[string]service.TemplateUsage, len(stats))
+	for _, usage := range stats {
+		byID[usage.ID] = usage
+	}
+	return byID
+}
+
+func TestUsageStats_RollingWindows(t *testing.T) {
+	s := setupSearchService()
+	now := time.Now()
+
+	s.RecordUsage("drake", now)
+	s.RecordUsage("drake", now.Add(-3*time.Hour))
+	s.RecordUsage("drake", now.Add(-3*24*time.Hour))
+	s.RecordUsage("drake", now.Add(-20*24*time.Hour))
+	s.RecordUsage("two-buttons", now.Add(-40*24*time.Hour))
+
+	stats := s.UsageStats()
+	require.Len(t, stats, 4, "every template should be listed, used or not")
+	assert.Equal(t, "drake", stats[0].ID, "most used template should come first")
+
+	byID := usageByID(stats)
+	assert.Equal(t, service.TemplateUsage{
+		ID: "drake", LastHour: 1, LastDay: 2, LastWeek: 3, LastMonth: 4, Total: 4,
+	}, byID["drake"])
+	assert.Equal(t, service.TemplateUsage{ID: "two-buttons", Total: 1}, byID["two-buttons"],
+		"usage older than a month should only count towards the total")
+	assert.Equal(t, service.TemplateUsage{ID: "change-my-mind"}, byID["change-my-mind"])
+}
+
+func TestUsageStats_Persistence(t *testing.T) {
+	cfg := &config.Config{StatsFile: filepath.Join(t.TempDir(), "data", "usage-stats.json")}
+
+	s := &service.MemeService{Config: cfg, Templates: setupSearchService().Templates}
+	s.RecordUsage("drake", time.Now())
+	s.RecordUsage("drake", time.Now().Add(-48*time.Hour))
+	require.NoError(t, s.SaveUsageStats())
+
+	restarted := &service.MemeService{Config: cfg, Templates: s.Templates}
+	require.NoError(t, restarted.LoadUsageStats())
+
+	usage := usageByID(restarted.UsageStats())["drake"]
+	assert.Equal(t, int64(2), usage.Total)
+	assert.Equal(t, int64(1), usage.LastDay)
+	assert.Equal(t, int64(2), usage.LastWeek)
+}
+
+func TestUsageStats_MissingFile(t *testing.T) {
+	s := &service.MemeService{
+		Config:    &config.Config{StatsFile: filepath.Join(t.TempDir(), "missing.json")},
+		Templates: setupSearchService().Templates,
+	}
+
+	require.NoError(t, s.LoadUsageStats())
+	assert.Equal(t, int64(0), usageByID(s.UsageStats())["drake"].Total)
+}
+
+func TestSearchTemplates_UsageOrders(t *testing.T) {
+	s := setupSearchService()
+	now := time.Now()
+
+	// drake is the most used this week, two-buttons is the most used today
+	for i := 0; i < 5; i++ {
+		s.RecordUsage("drake", now.Add(-5*24*time.Hour))
+	}
+	for i := 0; i < 3; i++ {
+		s.RecordUsage("two-buttons", now)
+	}
+	s.RecordUsage("change-my-mind", now)
+
+	tests := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{service.SortByTrending, []string{"two-buttons", "change-my-mind", "drake", "distracted-boyfriend"}},
+		{service.SortByPopular, []string{"drake", "two-buttons", "change-my-mind", "distracted-boyfriend"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			page, err := s.SearchTemplates(&service.TemplateQuery{SortBy: tt.sortBy})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, templateIDs(page.Templates))
+		})
+	}
+}
+
+func TestHandler_ListTemplatesTrending(t *testing.T) {
+	s := setupSearchService()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		s.RecordUsage("drake", now.Add(-5*24*time.Hour))
+	}
+	for i := 0; i < 3; i++ {
+		s.RecordUsage("two-buttons", now)
+	}
+	s.RecordUsage("change-my-mind", now)
+	h := handler.NewMemeHandler(s)
+
+	ids, _ := listTemplatesWithMetadata(t, h, &pb.ListTemplatesRequest{}, metadata.Pairs(handler.SortKey, service.SortByTrending))
+	assert.Equal(t, []string{"two-buttons", "change-my-mind", "drake", "distracted-boyfriend"}, ids)
+
+	// The category filter applies before ranking
+	ids, _ = listTemplatesWithMetadata(t, h, &pb.ListTemplatesRequest{Category: "classic"}, metadata.Pairs(handler.SortKey, service.SortByPopular))
+	assert.Equal(t, []string{"drake", "two-buttons", "distracted-boyfriend"}, ids)
+}
+
+func TestStatsHandler(t *testing.T) {
+	s := setupSearchService()
+	s.RecordUsage("change-my-mind", time.Now())
+
+	rec := httptest.NewRecorder()
+	handler.NewStatsHandler(s).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/stats", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Templates []service.TemplateUsage `json:"templates"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Templates, 4)
+	assert.Equal(t, "change-my-mind", response.Templates[0].ID)
+	assert.Equal(t, int64(1), response.Templates[0].LastHour)
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderStyled applies style to the drake template of a version test service and renders text
+func renderStyled(t *testing.T, style *service.TextStyle, text string) image.Image {
+	s := setupVersionService(t)
+	if style != nil {
+		_, err := s.UpdateTemplate("drake", &service.TemplateUpdate{TextStyle: style})
+		require.NoError(t, err)
+	}
+
+	resp, err := s.GenerateMeme(context.Background(), &pb.GenerateMemeRequest{TemplateId: "drake", TopText: text})
+	require.NoError(t, err)
+	require.Empty(t, resp.Error)
+
+	data, err := base64.StdEncoding.DecodeString(resp.ImageData)
+	require.NoError(t, err)
+	img, _, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	return img
+}
+
+// countPixels counts the pixels whose 8-bit colour satisfies match
+func countPixels(img image.Image, match func(r, g, b uint32) bool) int {
+	count := 0
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if match(r>>8, g>>8, b>>8) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func isWhite(r, g, b uint32) bool { return r > 230 && g > 230 && b > 230 }
+func isBlack(r, g, b uint32) bool { return r < 30 && g < 30 && b < 30 }
+func isGreen(r, g, b uint32) bool { return r < 60 && g > 200 && b < 60 }
+func isBlue(r, g, b uint32) bool  { return r < 60 && g < 60 && b > 200 }
+
+func intPtr(v int) *int { return &v }
+
+func TestTextStyle_Default(t *testing.T) {
+	img := renderStyled(t, nil, "HELLO")
+
+	assert.Positive(t, countPixels(img, isWhite))
+	assert.Positive(t, countPixels(img, isBlack))
+}
+
+func TestTextStyle_Colors(t *testing.T) {
+	img := renderStyled(t, &service.TextStyle{Color: "#00ff00", StrokeColor: "#0000ff", StrokeWidth: intPtr(3)}, "HELLO")
+
+	assert.Positive(t, countPixels(img, isGreen))
+	assert.Positive(t, countPixels(img, isBlue))
+	assert.Zero(t, countPixels(img, isWhite))
+	assert.Zero(t, countPixels(img, isBlack))
+}
+
+func TestTextStyle_NoStroke(t *testing.T) {
+	img := renderStyled(t, &service.TextStyle{Color: "#0f0", StrokeWidth: intPtr(0)}, "HELLO")
+
+	assert.Positive(t, countPixels(img, isGreen))
+	assert.Zero(t, countPixels(img, isBlack))
+}
+
+func TestTextStyle_UpperCase(t *testing.T) {
+	upper := renderStyled(t, &service.TextStyle{Case: service.CaseUpper}, "hello")
+	typed := renderStyled(t, &service.TextStyle{Case: service.CaseAsTyped}, "HELLO")
+	lower := renderStyled(t, &service.TextStyle{Case: service.CaseAsTyped}, "hello")
+
+	assert.Equal(t, typed, upper)
+	assert.NotEqual(t, lower, upper)
+}
+
+func TestTextStyle_MissingFontFallsBack(t *testing.T) {
+	img := renderStyled(t, &service.TextStyle{Font: "missing.ttf"}, "HELLO")
+
+	assert.Positive(t, countPixels(img, isWhite))
+}
+
+func TestTextStyle_ChangesVersion(t *testing.T) {
+	s := setupVersionService(t)
+	before, _ := s.Template("drake")
+
+	updated, err := s.UpdateTemplate("drake", &service.TemplateUpdate{TextStyle: &service.TextStyle{Color: "#000"}})
+	require.NoError(t, err)
+	assert.NotEqual(t, before.Version, updated.Version)
+}
+
+func TestTextStyle_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   string
+		wantErr string
+	}{
+		{"Valid", `{"font": "anton.ttf", "color": "#FFF", "stroke_color": "#00000080", "stroke_width": 4, "case": "upper", "align": "left"}`, ""},
+		{"Bad colour", `{"color": "white"}`, "must start with #"},
+		{"Short colour", `{"stroke_color": "#ff"}`, "must be #rgb, #rrggbb or #rrggbbaa"},
+		{"Not hexadecimal", `{"color": "#gggggg"}`, "is not hexadecimal"},
+		{"Negative stroke", `{"stroke_width": -1}`, "stroke_width must be between 0 and 20"},
+		{"Wide stroke", `{"stroke_width": 21}`, "stroke_width must be between 0 and 20"},
+		{"Unknown case", `{"case": "lower"}`, "case must be upper or as-typed"},
+		{"Unknown align", `{"align": "justify"}`, "align must be one of left, center, right, start or end"},
+		{"Font path", `{"font": "../impact.ttf"}`, "must not contain a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeManifestDir(t, `{"templates": [
+				{"id": "drake", "name": "Drake", "category": "classic", "filename": "drake.jpg", "text_field_count": 2,
+				 "text_style": `+tt.style+`}
+			]}`, "drake.jpg")
+
+			templates, err := service.LoadTemplates(dir)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "anton.ttf", templates["drake"].TextStyle.Font)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
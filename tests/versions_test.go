@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/RoMalms10/grpc/meme"
+	"github.com/RoMalms10/meme-generator/config"
+	"github.com/RoMalms10/meme-generator/handler"
+	"github.com/RoMalms10/meme-generator/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// setupVersionService creates a service over a template directory holding a 200x100 drake
+// template. The font file is missing, so the built-in font is used.
+func setupVersionService(t *testing.T) *service.MemeService {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, service.ManifestFilename), []byte(singleTemplateManifest), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "drake.jpg"), testImage(t, "jpeg", 200, 100), 0644))
+
+	s := &service.MemeService{
+		Config: &config.Config{
+			TemplateDir:          dir,
+			FontFile:             filepath.Join(dir, "missing.ttf"),
+			ImageQuality:         90,
+			FontSize:             36,
+			LineSpacing:          1.5,
+			MaxTemplateBytes:     1 << 20,
+			MaxTemplateDimension: 1024,
+		},
+	}
+	require.NoError(t, s.ReloadTemplates())
+
+	return s
+}
+
+// renderedSize decodes the dimensions of a generated meme
+func renderedSize(t *testing.T, resp *pb.GenerateMemeResponse) image.Point {
+	require.Empty(t, resp.Error)
+
+	data, err := base64.StdEncoding.DecodeString(resp.ImageData)
+	require.NoError(t, err)
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	return image.Pt(config.Width, config.Height)
+}
+
+// fakeTransportStream captures the headers a gRPC handler sets
+type fakeTransportStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (f *fakeTransportStream) Method() string { return "/meme.MemeService/GenerateMeme" }
+
+func (f *fakeTransportStream) SetHeader(md metadata.MD) error {
+	f.header = metadata.Join(f.header, md)
+	return nil
+}
+
+func TestTemplateVersions_ReplaceImage(t *testing.T) {
+	s := setupVersionService(t)
+	req := &pb.GenerateMemeRequest{TemplateId: "drake", TopText: "Top"}
+
+	original, _ := s.Template("drake")
+	require.Len(t, original.Version, 16)
+
+	_, err := s.ReplaceTemplateImage("drake", bytes.NewReader(testImage(t, "jpeg", 300, 300)))
+	require.NoError(t, err)
+	replaced, _ := s.Template("drake")
+	assert.NotEqual(t, original.Version, replaced.Version)
+
+	// Unpinned renders use the new image
+	resp, used, err := s.GenerateMemeVersion(context.Background(), req, "")
+	require.NoError(t, err)
+	assert.Equal(t, replaced.Version, used)
+	assert.Equal(t, image.Pt(300, 300), renderedSize(t, resp))
+
+	// Pinned renders reproduce the original
+	resp, used, err = s.GenerateMemeVersion(context.Background(), req, original.Version)
+	require.NoError(t, err)
+	assert.Equal(t, original.Version, used)
+	assert.Equal(t, image.Pt(200, 100), renderedSize(t, resp))
+}
+
+func TestTemplateVersions_ReplacedOnDisk(t *testing.T) {
+	s := setupVersionService(t)
+	original, _ := s.Template("drake")
+
+	// Replacing the file directly is picked up by a reload; the old version stays readable
+	require.NoError(t, os.WriteFile(filepath.Join(s.Config.TemplateDir, "drake.jpg"), testImage(t, "jpeg", 120, 80), 0644))
+	require.NoError(t, s.ReloadTemplates())
+
+	resp, _, err := s.GenerateMemeVersion(context.Background(), &pb.GenerateMemeRequest{TemplateId: "drake"}, original.Version)
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(200, 100), renderedSize(t, resp))
+
+	versions, err := s.TemplateVersions("drake")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	for _, version := range versions {
+		assert.Equal(t, version.Version != original.Version, version.Current)
+	}
+}
+
+func TestTemplateVersions_Metadata(t *testing.T) {
+	s := setupVersionService(t)
+	original, _ := s.Template("drake")
+
+	// Renaming does not change how the template renders
+	name := "Drake"
+	renamed, err := s.UpdateTemplate("drake", &service.TemplateUpdate{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, original.Version, renamed.Version)
+
+	// Adding text regions does
+	regions := []service.TextRegion{{Name: "top", X: 0, Y: 0, Width: 200, Height: 50}}
+	updated, err := s.UpdateTemplate("drake", &service.TemplateUpdate{TextRegions: &regions})
+	require.NoError(t, err)
+	assert.NotEqual(t, original.Version, updated.Version)
+
+	// The manifest does not store versions; they are derived on load
+	data, err := os.ReadFile(filepath.Join(s.Config.TemplateDir, service.ManifestFilename))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "version")
+}
+
+func TestTemplateVersions_DeletedTemplate(t *testing.T) {
+	s := setupVersionService(t)
+	original, _ := s.Template("drake")
+	require.NoError(t, s.DeleteTemplate("drake"))
+
+	req := &pb.GenerateMemeRequest{TemplateId: "drake"}
+	resp, err := s.GenerateMeme(context.Background(), req)
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "not found")
+
+	resp, _, err = s.GenerateMemeVersion(context.Background(), req, original.Version)
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(200, 100), renderedSize(t, resp))
+}
+
+func TestTemplateVersions_UnknownVersion(t *testing.T) {
+	s := setupVersionService(t)
+
+	for _, version := range []string{"0123456789abcdef", "../../manifest", "drake"} {
+		resp, used, err := s.GenerateMemeVersion(context.Background(), &pb.GenerateMemeRequest{TemplateId: "drake"}, version)
+		require.NoError(t, err)
+		assert.Contains(t, resp.Error, "has no version", version)
+		assert.Empty(t, used)
+	}
+
+	_, err := s.TemplateVersions("missing")
+	assert.ErrorIs(t, err, service.ErrTemplateNotFound)
+}
+
+func TestHandler_GenerateMemeVersionMetadata(t *testing.T) {
+	s := setupVersionService(t)
+	original, _ := s.Template("drake")
+	_, err := s.ReplaceTemplateImage("drake", bytes.NewReader(testImage(t, "jpeg", 300, 300)))
+	require.NoError(t, err)
+
+	h := handler.NewMemeHandler(s)
+	stream := &fakeTransportStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(handler.TemplateVersionKey, original.Version))
+
+	resp, err := h.GenerateMeme(ctx, &pb.GenerateMemeRequest{TemplateId: "drake"})
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(200, 100), renderedSize(t, resp))
+	assert.Equal(t, []string{original.Version}, stream.header.Get(handler.TemplateVersionKey))
+}
+
+func TestVersionsHandler(t *testing.T) {
+	s := setupVersionService(t)
+	_, err := s.ReplaceTemplateImage("drake", bytes.NewReader(testImage(t, "jpeg", 300, 300)))
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1/templates/{id}/versions", handler.NewVersionsHandler(s))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/templates/drake/versions", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Versions []service.TemplateVersion `json:"versions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response.Versions, 2)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/templates/missing/versions", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}